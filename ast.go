@@ -1,53 +1,585 @@
 package gosql
 
+import "fmt"
+
+// 语法树可以直接用 encoding/json 序列化。
+// 各种 kind 序列化成小写名字（比如 "select"、"literal"、"identifier"），
+// 语句只输出和 kind 对应的那个字段，例如：
+//
+//	{"kind":"select","select":{"item":[{"exp":{"kind":"literal","literal":{...}}}],"from":{...}}}
+//
+// token 输出全部字段，位置是从 0 开始的行列。
 type AstKind uint
 
 const (
 	SelectKind AstKind = iota
 	CreateTableKind
 	InsertKind
+	DeleteKind
+	UpdateKind
+	CreateIndexKind
+	CompoundSelectKind
+	ValuesKind
+	TruncateKind
+	// 事务控制语句没有其他内容
+	BeginKind
+	CommitKind
+	RollbackKind
+	ExplainKind
+	ShowKind
+	DescribeKind
+	CreateViewKind
+	DropViewKind
+	DropIndexKind
 )
 
-type expressionKind uint
+var astKindNames = map[AstKind]string{
+	SelectKind:         "select",
+	CreateTableKind:    "create_table",
+	InsertKind:         "insert",
+	DeleteKind:         "delete",
+	UpdateKind:         "update",
+	CreateIndexKind:    "create_index",
+	CompoundSelectKind: "compound_select",
+	ValuesKind:         "values",
+	TruncateKind:       "truncate",
+	BeginKind:          "begin",
+	CommitKind:         "commit",
+	RollbackKind:       "rollback",
+	ExplainKind:        "explain",
+	ShowKind:           "show",
+	DescribeKind:       "describe",
+	CreateViewKind:     "create_view",
+	DropViewKind:       "drop_view",
+	DropIndexKind:      "drop_index",
+}
+
+func (k AstKind) String() string {
+	if name, ok := astKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("AstKind(%d)", uint(k))
+}
+
+func (k AstKind) MarshalText() ([]byte, error) {
+	if name, ok := astKindNames[k]; ok {
+		return []byte(name), nil
+	}
+	return nil, fmt.Errorf("unknown statement kind %d", uint(k))
+}
+
+func (k *AstKind) UnmarshalText(text []byte) error {
+	for kind, name := range astKindNames {
+		if name == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown statement kind %q", text)
+}
+
+type ExpressionKind uint
 
 const (
-	literalKind expressionKind = iota
+	LiteralKind ExpressionKind = iota
+	BinaryKind
+	UnaryKind
+	FunctionCallKind
+	CaseKind
+	CastKind
+	InKind
+	BetweenKind
+	LikeKind
+	IsNullKind
+	ExistsKind
+	ColumnKind
+	TypedLiteralKind
 )
 
-type expression struct {
-	literal *Token
+var expressionKindNames = map[ExpressionKind]string{
+	LiteralKind:      "literal",
+	BinaryKind:       "binary",
+	UnaryKind:        "unary",
+	FunctionCallKind: "call",
+	CaseKind:         "case",
+	CastKind:         "cast",
+	InKind:           "in",
+	BetweenKind:      "between",
+	LikeKind:         "like",
+	IsNullKind:       "isNull",
+	ExistsKind:       "exists",
+	ColumnKind:       "column",
+	TypedLiteralKind: "typedLiteral",
+}
+
+func (k ExpressionKind) String() string {
+	if name, ok := expressionKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("ExpressionKind(%d)", uint(k))
+}
+
+func (k ExpressionKind) MarshalText() ([]byte, error) {
+	if name, ok := expressionKindNames[k]; ok {
+		return []byte(name), nil
+	}
+	return nil, fmt.Errorf("unknown expression kind %d", uint(k))
+}
+
+func (k *ExpressionKind) UnmarshalText(text []byte) error {
+	for kind, name := range expressionKindNames {
+		if name == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown expression kind %q", text)
+}
+
+// Expression 的 Kind 决定哪个字段有值。
+// Start 和 End 是表达式第一个 token 的起始位置和最后一个 token 的结束位置，
+// 外层的括号不算在内
+type Expression struct {
+	Literal      *Token                  `json:"literal,omitempty"`
+	Binary       *BinaryExpression       `json:"binary,omitempty"`
+	Unary        *UnaryExpression        `json:"unary,omitempty"`
+	Call         *FunctionCallExpression `json:"call,omitempty"`
+	Case         *CaseExpression         `json:"case,omitempty"`
+	Cast         *CastExpression         `json:"cast,omitempty"`
+	In           *InExpression           `json:"in,omitempty"`
+	Between      *BetweenExpression      `json:"between,omitempty"`
+	Like         *LikeExpression         `json:"like,omitempty"`
+	IsNull       *IsNullExpression       `json:"isNull,omitempty"`
+	Exists       *ExistsExpression       `json:"exists,omitempty"`
+	Column       *ColumnReference        `json:"column,omitempty"`
+	TypedLiteral *TypedLiteralExpression `json:"typedLiteral,omitempty"`
+	Kind         ExpressionKind          `json:"kind"`
+	Start        Location                `json:"start"`
+	End          Location                `json:"end"`
+}
+
+// 二元表达式 A Op B，Op 是运算符或 and、or 关键字
+type BinaryExpression struct {
+	A  *Expression `json:"a"`
+	B  *Expression `json:"b"`
+	Op Token       `json:"op"`
+}
+
+// 一元表达式 Op Operand，Op 是 not、+ 或 -
+type UnaryExpression struct {
+	Op      Token       `json:"op"`
+	Operand *Expression `json:"operand"`
+}
+
+// 函数调用 Name(Args)，Args 可以为空。count(*) 没有参数，Asterisk 为 true；
+// Distinct 表示 count(distinct x) 这样只对不同的值求聚合。
+type FunctionCallExpression struct {
+	Name     Token         `json:"name"`
+	Args     []*Expression `json:"args"`
+	Asterisk bool          `json:"asterisk,omitempty"`
+	Distinct bool          `json:"distinct,omitempty"`
+}
+
+// case [Operand] when ... then ... [else Else] end。
+// 有 Operand 时依次和每个 When 比较是否相等，否则 When 本身是条件。
+type CaseExpression struct {
+	Operand *Expression   `json:"operand,omitempty"`
+	Whens   []*WhenClause `json:"whens"`
+	Else    *Expression   `json:"else,omitempty"`
+}
+
+// cast(Exp as Type) 或者 Exp::Type，Type 是 int、text 这样的类型关键字
+type CastExpression struct {
+	Exp  *Expression `json:"exp"`
+	Type Token       `json:"type"`
 }
 
-// 插入语句具有表名和要插入的值列表：
+// 带类型的字面量 Type 'Value'，比如 date '2024-01-15'。
+// Type 是 date、time 或 timestamp，Value 是格式已经检查过的字符串
+type TypedLiteralExpression struct {
+	Type  Token `json:"type"`
+	Value Token `json:"value"`
+}
+
+// Left [not] in (List) 或 Left [not] in (Select)，List 和 Select 只有一个有值
+type InExpression struct {
+	Left   *Expression      `json:"left"`
+	Not    bool             `json:"not,omitempty"`
+	List   []*Expression    `json:"list,omitempty"`
+	Select *SelectStatement `json:"select,omitempty"`
+}
+
+// Exp [not] between Low and High，两端都包含
+type BetweenExpression struct {
+	Exp  *Expression `json:"exp"`
+	Not  bool        `json:"not,omitempty"`
+	Low  *Expression `json:"low"`
+	High *Expression `json:"high"`
+}
+
+// Exp [not] like Pattern [escape Escape]，Pattern 里 % 匹配任意串，_ 匹配单个字符，
+// Escape 给出让它们按字面匹配的转义字符
+type LikeExpression struct {
+	Exp     *Expression `json:"exp"`
+	Not     bool        `json:"not,omitempty"`
+	Pattern *Expression `json:"pattern"`
+	Escape  *Expression `json:"escape,omitempty"`
+}
+
+// 后缀的 Exp is [not] null
+type IsNullExpression struct {
+	Exp *Expression `json:"exp"`
+	Not bool        `json:"not,omitempty"`
+}
+
+// ColumnReference 是表达式中的列名，比如 id 或者带表名、别名限定的 u.id，
+// 不带限定时 Qualifier 为 nil。
+type ColumnReference struct {
+	Qualifier *Token `json:"qualifier,omitempty"`
+	Name      Token  `json:"name"`
+}
+
+// exists (Select)，子查询至少返回一行时为真。not exists 是套在外面的一元 not
+type ExistsExpression struct {
+	Select *SelectStatement `json:"select"`
+}
+
+// WhenClause 是 case 中的 when When then Then
+type WhenClause struct {
+	When *Expression `json:"when"`
+	Then *Expression `json:"then"`
+}
+
+// 插入语句具有表名和要插入的若干行值，Values 中每个元素是一行。
+// Cols 是可选的列名列表，有列名时每行值的个数和列数相同，
+// 没有列名时每行值的个数都相同。
+// 要插入的行也可以来自 Select 的结果，Values 和 Select 只有一个有值：
 type InsertStatement struct {
-	table  Token
-	values *[]expression
+	Table  Token            `json:"table"`
+	Cols   []Token          `json:"cols,omitempty"`
+	Values [][]*Expression  `json:"values,omitempty"`
+	Select *SelectStatement `json:"select,omitempty"`
+	Start  Location         `json:"start"`
+	End    Location         `json:"end"`
+}
+
+// 创建语句具有表名以及列名和类型的列表，Default 是可选的默认值表达式，
+// Checks 是这一列上的 check 约束，References 是这一列上的外键。
+// Params 是类型后面括号里的参数，比如 varchar(255) 的 255、numeric(10, 2) 的 10 和 2：
+type ColumnDefinition struct {
+	Name       Token         `json:"name"`
+	Datatype   Token         `json:"datatype"`
+	Params     []Token       `json:"params,omitempty"`
+	PrimaryKey bool          `json:"primaryKey,omitempty"`
+	NotNull    bool          `json:"notNull,omitempty"`
+	Unique     bool          `json:"unique,omitempty"`
+	Default    *Expression   `json:"default,omitempty"`
+	Checks     []*Expression `json:"checks,omitempty"`
+	References *ForeignKey   `json:"references,omitempty"`
 }
 
-// 创建语句具有表名以及列名和类型的列表：
-type columnDefinition struct {
-	name     Token
-	datatype Token
+// ForeignKey 表示 Cols 引用表 Table 中的 RefCols。
+// 写在列定义上时 Cols 就是这一列；RefCols 为 nil 时引用的是 Table 的主键。
+type ForeignKey struct {
+	Cols    []Token `json:"cols"`
+	Table   Token   `json:"table"`
+	RefCols []Token `json:"refCols,omitempty"`
 }
 
+// IfNotExists 为 true 时表已经存在不算错误，什么也不做。
+// PrimaryKey 是表级的 primary key (...) 约束中的列名，
+// 主键写在列定义上时它为 nil，一张表最多只有一个主键。
+// UniqueConstraints 是表级 unique (...) 约束，每个元素是一个约束中的列名。
+// ForeignKeys 是表级 foreign key (...) references ... 约束。
+// Checks 是表级 check (...) 约束，表达式可以引用多个列。
 type CreateTableStatement struct {
-	name Token
-	cols *[]*columnDefinition
+	Name              Token               `json:"name"`
+	Cols              []*ColumnDefinition `json:"cols"`
+	IfNotExists       bool                `json:"ifNotExists,omitempty"`
+	PrimaryKey        []Token             `json:"primaryKey,omitempty"`
+	UniqueConstraints [][]Token           `json:"uniqueConstraints,omitempty"`
+	ForeignKeys       []*ForeignKey       `json:"foreignKeys,omitempty"`
+	Checks            []*Expression       `json:"checks,omitempty"`
+	Start             Location            `json:"start"`
+	End               Location            `json:"end"`
 }
 
-// select语句有一个表名和一个列名列表
+// SelectItem 是投影中的一列：Asterisk 表示 *，否则 Exp 是列的表达式，
+// As 是可选的别名。名字叫 * 的列要写成 "*"，是 Exp 中的标识符。
+type SelectItem struct {
+	Asterisk bool        `json:"asterisk,omitempty"`
+	Exp      *Expression `json:"exp,omitempty"`
+	As       *Token      `json:"as,omitempty"`
+}
+
+// TableReference 是 from 后面引用的表，Name、Select 和 Values 只有一个有值。
+// Select 和 Values 是括号里的派生表，这时 Alias 一定有值，Cols 是可选的列名；
+// 否则 Alias 是可选的别名。
+type TableReference struct {
+	Name   *Token           `json:"name,omitempty"`
+	Select *SelectStatement `json:"select,omitempty"`
+	Values *ValuesStatement `json:"values,omitempty"`
+	Alias  *Token           `json:"alias,omitempty"`
+	Cols   []Token          `json:"cols,omitempty"`
+}
+
+type JoinKind uint
+
+const (
+	InnerJoin JoinKind = iota
+	LeftJoin
+	RightJoin
+	FullJoin
+)
+
+var joinKindNames = map[JoinKind]string{
+	InnerJoin: "inner",
+	LeftJoin:  "left",
+	RightJoin: "right",
+	FullJoin:  "full",
+}
+
+func (k JoinKind) String() string {
+	if name, ok := joinKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("JoinKind(%d)", uint(k))
+}
+
+func (k JoinKind) MarshalText() ([]byte, error) {
+	if name, ok := joinKindNames[k]; ok {
+		return []byte(name), nil
+	}
+	return nil, fmt.Errorf("unknown join kind %d", uint(k))
+}
+
+func (k *JoinKind) UnmarshalText(text []byte) error {
+	for kind, name := range joinKindNames {
+		if name == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown join kind %q", text)
+}
+
+// Join 是 from 后面的 [inner] join Table on On，或者 left、right、full [outer] join。多个 Join 从左到右依次
+// 和前面的结果连接，a join b on ... join c on ... 是 (a join b) join c。
+type Join struct {
+	Kind  JoinKind        `json:"kind"`
+	Table *TableReference `json:"table"`
+	On    *Expression     `json:"on"`
+}
+
+// OrderByItem 是 order by 中的一项，Asc 为 false 表示降序
+type OrderByItem struct {
+	Exp *Expression `json:"exp"`
+	Asc bool        `json:"asc"`
+}
+
+// select语句有一个表和一个列名列表，其他子句都可以为空。
+// 没有 From 时投影列在一行不含任何列的空行上求值。
+// 没有 GroupBy 时 Having 把整个结果当作一个分组。
+// Limit 和 Offset 是非负整数字面量或参数。Distinct 表示去掉重复的行。
 type SelectStatement struct {
-	item []*expression
-	from Token
+	Distinct bool            `json:"distinct,omitempty"`
+	Item     []*SelectItem   `json:"item"`
+	From     *TableReference `json:"from,omitempty"`
+	Joins    []*Join         `json:"joins,omitempty"`
+	Where    *Expression     `json:"where,omitempty"`
+	GroupBy  []*Expression   `json:"groupBy,omitempty"`
+	Having   *Expression     `json:"having,omitempty"`
+	OrderBy  []*OrderByItem  `json:"orderBy,omitempty"`
+	Limit    *Expression     `json:"limit,omitempty"`
+	Offset   *Expression     `json:"offset,omitempty"`
+	Start    Location        `json:"start"`
+	End      Location        `json:"end"`
+}
+
+// SetOperator 是连接两个查询的 union、intersect 或 except，
+// All 为 true 时保留重复的行
+type SetOperator struct {
+	Op  Token `json:"op"`
+	All bool  `json:"all,omitempty"`
+}
+
+// SetOperand 是集合运算的一个操作数，Select 和 Compound 只有一个有值。
+// Compound 是优先级更高的 intersect，或者括号里的集合运算。
+type SetOperand struct {
+	Select   *SelectStatement         `json:"select,omitempty"`
+	Compound *CompoundSelectStatement `json:"compound,omitempty"`
+}
+
+// 用集合运算连接起来的多个查询，Ops[i] 连接 Selects[i] 和 Selects[i+1]，
+// 从左到右依次计算。同一个 CompoundSelectStatement 里的运算符优先级相同：
+// 要么都是 intersect，要么都是 union 或 except。
+// OrderBy、Limit 和 Offset 作用在整个结果上。
+type CompoundSelectStatement struct {
+	Selects []*SetOperand  `json:"selects"`
+	Ops     []*SetOperator `json:"ops"`
+	OrderBy []*OrderByItem `json:"orderBy,omitempty"`
+	Limit   *Expression    `json:"limit,omitempty"`
+	Offset  *Expression    `json:"offset,omitempty"`
+	Start   Location       `json:"start"`
+	End     Location       `json:"end"`
+}
+
+// values (...)[, (...) ...] 单独作为语句或者派生表，每行的值的个数相同
+type ValuesStatement struct {
+	Rows  [][]*Expression `json:"rows"`
+	Start Location        `json:"start"`
+	End   Location        `json:"end"`
+}
+
+// 创建索引语句在表 Table 的 Cols 上创建名为 Name 的索引，Cols 中没有重复的列
+type CreateIndexStatement struct {
+	Name   Token    `json:"name"`
+	Table  Token    `json:"table"`
+	Unique bool     `json:"unique,omitempty"`
+	Cols   []Token  `json:"cols"`
+	Start  Location `json:"start"`
+	End    Location `json:"end"`
+}
+
+// 创建视图语句把查询 Select 保存为名为 Name 的视图，
+// OrReplace 为 true 时替换同名的视图
+type CreateViewStatement struct {
+	Name      Token            `json:"name"`
+	OrReplace bool             `json:"orReplace,omitempty"`
+	Select    *SelectStatement `json:"select"`
+	Start     Location         `json:"start"`
+	End       Location         `json:"end"`
+}
+
+// 删除名为 Name 的视图
+type DropViewStatement struct {
+	Name  Token    `json:"name"`
+	Start Location `json:"start"`
+	End   Location `json:"end"`
+}
+
+// 删除名为 Name 的索引，IfExists 为 true 时索引不存在不算错误。
+// Table 是 MySQL 风格的 on <表名>，省略时为 nil
+type DropIndexStatement struct {
+	Name     Token    `json:"name"`
+	IfExists bool     `json:"ifExists,omitempty"`
+	Table    *Token   `json:"table,omitempty"`
+	Start    Location `json:"start"`
+	End      Location `json:"end"`
+}
+
+// 删除语句从表 From 中删除满足 Where 的行，没有 Where 时删除所有行
+type DeleteStatement struct {
+	From  Token       `json:"from"`
+	Where *Expression `json:"where,omitempty"`
+	Start Location    `json:"start"`
+	End   Location    `json:"end"`
+}
+
+// Assignment 是 update 中的 Column = Value
+type Assignment struct {
+	Column Token       `json:"column"`
+	Value  *Expression `json:"value"`
+}
+
+// explain [analyze] Statement 输出 Statement 的执行计划，
+// Analyze 为 true 时实际执行一遍。Statement 不会是另一个 explain。
+type ExplainStatement struct {
+	Analyze   bool       `json:"analyze,omitempty"`
+	Statement *Statement `json:"statement"`
+	Start     Location   `json:"start"`
+	End       Location   `json:"end"`
+}
+
+// ShowTarget 是 show 语句要列出的对象
+type ShowTarget uint
+
+const (
+	ShowTables ShowTarget = iota
+)
+
+var showTargetNames = map[ShowTarget]string{
+	ShowTables: "tables",
+}
+
+func (t ShowTarget) String() string {
+	if name, ok := showTargetNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("ShowTarget(%d)", uint(t))
+}
+
+func (t ShowTarget) MarshalText() ([]byte, error) {
+	if name, ok := showTargetNames[t]; ok {
+		return []byte(name), nil
+	}
+	return nil, fmt.Errorf("unknown show target %d", uint(t))
+}
+
+func (t *ShowTarget) UnmarshalText(text []byte) error {
+	for target, name := range showTargetNames {
+		if name == string(text) {
+			*t = target
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown show target %q", text)
+}
+
+// show tables 列出所有的表，结果只有一列表名，按字母顺序排列
+type ShowStatement struct {
+	Target ShowTarget `json:"target"`
+	Start  Location   `json:"start"`
+	End    Location   `json:"end"`
+}
+
+// describe Table 或 desc Table 列出表的列名、类型和约束
+type DescribeStatement struct {
+	Table Token    `json:"table"`
+	Start Location `json:"start"`
+	End   Location `json:"end"`
+}
+
+// 清空语句删除 Tables 中每个表的所有行，表结构保持不变
+type TruncateStatement struct {
+	Tables []Token  `json:"tables"`
+	Start  Location `json:"start"`
+	End    Location `json:"end"`
+}
+
+// 更新语句对表 Table 中满足 Where 的行执行 Set 中的赋值，没有 Where 时更新所有行
+type UpdateStatement struct {
+	Table Token         `json:"table"`
+	Set   []*Assignment `json:"set"`
+	Where *Expression   `json:"where,omitempty"`
+	Start Location      `json:"start"`
+	End   Location      `json:"end"`
 }
 
+// Statement 的 Kind 决定哪个字段有值。
+// 每种语句和 Statement 本身都记录 Start 和 End：第一个 token 的起始位置
+// 和最后一个 token 的结束位置，不包括语句后面的分号，语义检查用它们报告错误
 type Statement struct {
-	SelectStatement      *SelectStatement
-	CreateTableStatement *CreateTableStatement
-	InsertStatement      *InsertStatement
-	Kind                 AstKind
+	SelectStatement         *SelectStatement         `json:"select,omitempty"`
+	CreateTableStatement    *CreateTableStatement    `json:"createTable,omitempty"`
+	InsertStatement         *InsertStatement         `json:"insert,omitempty"`
+	DeleteStatement         *DeleteStatement         `json:"delete,omitempty"`
+	UpdateStatement         *UpdateStatement         `json:"update,omitempty"`
+	CreateIndexStatement    *CreateIndexStatement    `json:"createIndex,omitempty"`
+	CompoundSelectStatement *CompoundSelectStatement `json:"compoundSelect,omitempty"`
+	ValuesStatement         *ValuesStatement         `json:"values,omitempty"`
+	TruncateStatement       *TruncateStatement       `json:"truncate,omitempty"`
+	ExplainStatement        *ExplainStatement        `json:"explain,omitempty"`
+	ShowStatement           *ShowStatement           `json:"show,omitempty"`
+	DescribeStatement       *DescribeStatement       `json:"describe,omitempty"`
+	CreateViewStatement     *CreateViewStatement     `json:"createView,omitempty"`
+	DropViewStatement       *DropViewStatement       `json:"dropView,omitempty"`
+	DropIndexStatement      *DropIndexStatement      `json:"dropIndex,omitempty"`
+	Kind                    AstKind                  `json:"kind"`
+	Start                   Location                 `json:"start"`
+	End                     Location                 `json:"end"`
 }
 
 type Ast struct {
-	Statements []*Statement
+	Statements []*Statement `json:"statements"`
 }
@@ -0,0 +1,89 @@
+package gosql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAst_json(t *testing.T) {
+	source := `create table users (id int, name text);
+insert into users values (1, 'Ada');
+select id, name, -5, :p from users;
+select * from users where id = 1 and not name <> 'x';
+delete from users where id = 2`
+
+	ast, err := Parse(source)
+	assert.Nil(t, err)
+
+	data, err := json.Marshal(ast)
+	assert.Nil(t, err)
+
+	var decoded Ast
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, ast, &decoded)
+}
+
+func TestAst_jsonShape(t *testing.T) {
+	ast, err := Parse("select a from t")
+	assert.Nil(t, err)
+
+	data, err := json.Marshal(ast)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"statements": [{
+			"kind": "select",
+			"select": {
+				"item": [{
+					"exp": {
+						"kind": "column",
+						"column": {
+							"name": {"value": "a", "raw": "a", "kind": "identifier", "loc": {"line": 0, "col": 7}, "end": {"line": 0, "col": 8}, "pos": 7, "endPos": 8}
+						},
+						"start": {"line": 0, "col": 7},
+						"end": {"line": 0, "col": 8}
+					}
+				}],
+				"from": {
+					"name": {"value": "t", "raw": "t", "kind": "identifier", "loc": {"line": 0, "col": 14}, "end": {"line": 0, "col": 15}, "pos": 14, "endPos": 15}
+				},
+				"start": {"line": 0, "col": 0},
+				"end": {"line": 0, "col": 15}
+			},
+			"start": {"line": 0, "col": 0},
+			"end": {"line": 0, "col": 15}
+		}]
+	}`, string(data))
+}
+
+func TestAst_jsonUnknownKind(t *testing.T) {
+	var stmt Statement
+	err := json.Unmarshal([]byte(`{"kind": "drop"}`), &stmt)
+	assert.EqualError(t, err, `unknown statement kind "drop"`)
+
+	var tok Token
+	err = json.Unmarshal([]byte(`{"kind": "bogus"}`), &tok)
+	assert.EqualError(t, err, `unknown token kind "bogus"`)
+
+	_, err = json.Marshal(Statement{Kind: AstKind(42)})
+	assert.NotNil(t, err)
+}
+
+func TestAst_jsonShow(t *testing.T) {
+	ast, err := Parse("show tables")
+	assert.Nil(t, err)
+
+	data, err := json.Marshal(ast)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"statements": [{
+		"kind": "show",
+		"show": {"target": "tables", "start": {"line": 0, "col": 0}, "end": {"line": 0, "col": 11}},
+		"start": {"line": 0, "col": 0},
+		"end": {"line": 0, "col": 11}
+	}]}`, string(data))
+
+	var decoded Ast
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, ast, &decoded)
+}
@@ -0,0 +1,59 @@
+package gosql
+
+import "fmt"
+
+// ColumnType 是后端中一列的类型，由列定义中的类型关键字决定
+type ColumnType uint
+
+const (
+	IntType ColumnType = iota
+	TextType
+	BoolType
+)
+
+var columnTypeNames = map[ColumnType]string{
+	IntType:  "int",
+	TextType: "text",
+	BoolType: "bool",
+}
+
+func (t ColumnType) String() string {
+	if name, ok := columnTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("ColumnType(%d)", uint(t))
+}
+
+// ResultColumn 是查询结果中的一列
+type ResultColumn struct {
+	Name string
+	Type ColumnType
+}
+
+// Results 是 select 的结果，Rows 中每一行的值和 Columns 一一对应，
+// 值的类型就是所在列的 Type
+type Results struct {
+	Columns []ResultColumn
+	Rows    [][]Cell
+}
+
+// Backend 执行解析好的语句
+type Backend interface {
+	CreateTable(*CreateTableStatement) error
+	Insert(*InsertStatement) error
+	Select(*SelectStatement) (*Results, error)
+}
+
+// ExecError 是执行语句失败时返回的错误，Loc 是出错的节点或 token 的位置
+type ExecError struct {
+	Loc Location
+	Msg string
+}
+
+func (e *ExecError) Error() string {
+	return fmt.Sprintf("%s at line %d col %d", e.Msg, e.Loc.Line+1, e.Loc.Col+1)
+}
+
+func execErrorAt(loc Location, format string, args ...interface{}) error {
+	return &ExecError{Loc: loc, Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,88 @@
+package gosql
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Cell 是结果中的一个值。值是对应的类型时访问方法返回 true，
+// 否则返回零值和 false，调用方不需要再解析字符串。
+type Cell interface {
+	AsInt() (int64, bool)
+	AsText() (string, bool)
+	AsBool() (bool, bool)
+}
+
+type intCell int64
+
+func (c intCell) AsInt() (int64, bool)   { return int64(c), true }
+func (c intCell) AsText() (string, bool) { return "", false }
+func (c intCell) AsBool() (bool, bool)   { return false, false }
+
+type textCell string
+
+func (c textCell) AsInt() (int64, bool)   { return 0, false }
+func (c textCell) AsText() (string, bool) { return string(c), true }
+func (c textCell) AsBool() (bool, bool)   { return false, false }
+
+type boolCell bool
+
+func (c boolCell) AsInt() (int64, bool)   { return 0, false }
+func (c boolCell) AsText() (string, bool) { return "", false }
+func (c boolCell) AsBool() (bool, bool)   { return bool(c), true }
+
+// LiteralCell 把字面量 token 转换成值，同时返回值的类型：
+// 数字是 int，必须是 64 位范围内的整数，可以是 0x 开头的十六进制；
+// 字符串是 text，空字符串也是一个值；true 和 false 是 bool。
+// 其他 token（包括 null 和参数）返回 ExecError。
+func LiteralCell(tok *Token) (Cell, ColumnType, error) {
+	switch tok.Kind {
+	case NumericKind:
+		digits := strings.TrimLeft(tok.Value, "+-")
+		base := 10
+		if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+			base = 0
+		}
+		i, err := strconv.ParseInt(tok.Value, base, 64)
+		if err != nil {
+			if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+				return nil, 0, execErrorAt(tok.Loc, "numeric literal %s is out of range for type int", tok.Value)
+			}
+			return nil, 0, execErrorAt(tok.Loc, "numeric literal %s is not an integer", tok.Value)
+		}
+		return intCell(i), IntType, nil
+	case StringKind:
+		return textCell(tok.Value), TextType, nil
+	case BoolKind:
+		return boolCell(tok.Value == "true"), BoolType, nil
+	}
+	return nil, 0, execErrorAt(tok.Loc, "%s %s is not a supported literal value", tok.Kind, tok.Value)
+}
+
+// compareCells 比较两个同类型的值，a 小于、等于、大于 b 时分别返回 -1、0、1。
+// 文本按字节比较，false 小于 true。
+func compareCells(a, b Cell) int {
+	if x, ok := a.AsInt(); ok {
+		y, _ := b.AsInt()
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	if x, ok := a.AsText(); ok {
+		y, _ := b.AsText()
+		return strings.Compare(x, y)
+	}
+	x, _ := a.AsBool()
+	y, _ := b.AsBool()
+	switch {
+	case x == y:
+		return 0
+	case !x:
+		return -1
+	}
+	return 1
+}
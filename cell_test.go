@@ -0,0 +1,75 @@
+package gosql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiteralCell(t *testing.T) {
+	tests := []struct {
+		tok  *Token
+		cell Cell
+		typ  ColumnType
+		err  string
+	}{
+		{tok: tok("42", NumericKind, 0), cell: intCell(42), typ: IntType},
+		{tok: tok("-5", NumericKind, 0), cell: intCell(-5), typ: IntType},
+		{tok: tok("0", NumericKind, 0), cell: intCell(0), typ: IntType},
+		{tok: tok("007", NumericKind, 0), cell: intCell(7), typ: IntType},
+		{tok: tok("0x1F", NumericKind, 0), cell: intCell(31), typ: IntType},
+		{tok: tok("-0X10", NumericKind, 0), cell: intCell(-16), typ: IntType},
+		{tok: tok("9223372036854775807", NumericKind, 0), cell: intCell(9223372036854775807), typ: IntType},
+		{tok: tok("-9223372036854775808", NumericKind, 0), cell: intCell(-9223372036854775808), typ: IntType},
+		{tok: tok("9223372036854775808", NumericKind, 3), err: "numeric literal 9223372036854775808 is out of range for type int at line 1 col 4"},
+		{tok: tok("-9223372036854775809", NumericKind, 0), err: "numeric literal -9223372036854775809 is out of range for type int at line 1 col 1"},
+		{tok: tok("0x10000000000000000", NumericKind, 0), err: "numeric literal 0x10000000000000000 is out of range for type int at line 1 col 1"},
+		{tok: tok("3.5", NumericKind, 0), err: "numeric literal 3.5 is not an integer at line 1 col 1"},
+		{tok: tok("1e10", NumericKind, 0), err: "numeric literal 1e10 is not an integer at line 1 col 1"},
+		{tok: tok("Ada", StringKind, 0), cell: textCell("Ada"), typ: TextType},
+		{tok: tok("", StringKind, 0), cell: textCell(""), typ: TextType},
+		{tok: tok("42", StringKind, 0), cell: textCell("42"), typ: TextType},
+		{tok: tok("true", BoolKind, 0), cell: boolCell(true), typ: BoolType},
+		{tok: tok("false", BoolKind, 0), cell: boolCell(false), typ: BoolType},
+		{tok: tok("null", KeywordKind, 7), err: "keyword null is not a supported literal value at line 1 col 8"},
+		{tok: tok("id", IdentifierKind, 0), err: "identifier id is not a supported literal value at line 1 col 1"},
+		{tok: tok("$1", ParameterKind, 0), err: "parameter $1 is not a supported literal value at line 1 col 1"},
+	}
+
+	for _, test := range tests {
+		cell, typ, err := LiteralCell(test.tok)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.tok.Value)
+			continue
+		}
+		assert.Nil(t, err, test.tok.Value)
+		assert.Equal(t, test.cell, cell, test.tok.Value)
+		assert.Equal(t, test.typ, typ, test.tok.Value)
+	}
+}
+
+func TestCell_accessors(t *testing.T) {
+	i, ok := intCell(7).AsInt()
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), i)
+	_, ok = intCell(7).AsText()
+	assert.False(t, ok)
+	_, ok = intCell(1).AsBool()
+	assert.False(t, ok)
+
+	s, ok := textCell("7").AsText()
+	assert.True(t, ok)
+	assert.Equal(t, "7", s)
+	_, ok = textCell("7").AsInt()
+	assert.False(t, ok)
+	_, ok = textCell("true").AsBool()
+	assert.False(t, ok)
+
+	b, ok := boolCell(true).AsBool()
+	assert.True(t, ok)
+	assert.True(t, b)
+	_, ok = boolCell(true).AsInt()
+	assert.False(t, ok)
+	_, ok = boolCell(true).AsText()
+	assert.False(t, ok)
+}
@@ -0,0 +1,584 @@
+package gosql
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 把语法树还原成 SQL 文本。输出是规范化的：关键字小写、单个空格分隔，
+// 标识符只在必要时加双引号，字符串中的撇号写成两个撇号。
+// 对输出再次调用 Parse 会得到相同的语法树（位置信息除外）。
+
+// String 输出所有语句，每条语句以分号结尾，各占一行
+func (a *Ast) String() string {
+	var b strings.Builder
+	for i, stmt := range a.Statements {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(stmt.String())
+		b.WriteByte(';')
+	}
+	return b.String()
+}
+
+// String 输出语句本身，不带结尾的分号
+func (s *Statement) String() string {
+	switch s.Kind {
+	case SelectKind:
+		return s.SelectStatement.String()
+	case InsertKind:
+		return s.InsertStatement.String()
+	case CreateTableKind:
+		return s.CreateTableStatement.String()
+	case DeleteKind:
+		return s.DeleteStatement.String()
+	case UpdateKind:
+		return s.UpdateStatement.String()
+	case CreateIndexKind:
+		return s.CreateIndexStatement.String()
+	case CompoundSelectKind:
+		return s.CompoundSelectStatement.String()
+	case ValuesKind:
+		return s.ValuesStatement.String()
+	case TruncateKind:
+		return s.TruncateStatement.String()
+	case BeginKind, CommitKind, RollbackKind:
+		return s.Kind.String()
+	case ExplainKind:
+		return s.ExplainStatement.String()
+	case ShowKind:
+		return s.ShowStatement.String()
+	case DescribeKind:
+		return s.DescribeStatement.String()
+	case CreateViewKind:
+		return s.CreateViewStatement.String()
+	case DropViewKind:
+		return s.DropViewStatement.String()
+	case DropIndexKind:
+		return s.DropIndexStatement.String()
+	}
+	return ""
+}
+
+func (s *SelectStatement) String() string {
+	items := make([]string, len(s.Item))
+	for i, item := range s.Item {
+		items[i] = item.String()
+	}
+	sql := "select "
+	if s.Distinct {
+		sql += "distinct "
+	}
+	sql += strings.Join(items, ", ")
+	if s.From != nil {
+		sql += " from " + s.From.String()
+	}
+	for _, join := range s.Joins {
+		sql += " " + join.String()
+	}
+	if s.Where != nil {
+		sql += " where " + s.Where.String()
+	}
+	if s.GroupBy != nil {
+		sql += " group by " + expressionList(s.GroupBy)
+	}
+	if s.Having != nil {
+		sql += " having " + s.Having.String()
+	}
+	return sql + orderByString(s.OrderBy, s.Limit, s.Offset)
+}
+
+// order by、limit 和 offset 子句，前面带空格
+func orderByString(items []*OrderByItem, limit, offset *Expression) string {
+	sql := ""
+	if items != nil {
+		orderBy := make([]string, len(items))
+		for i, item := range items {
+			orderBy[i] = item.String()
+		}
+		sql += " order by " + strings.Join(orderBy, ", ")
+	}
+	if limit != nil {
+		sql += " limit " + limit.String()
+	}
+	if offset != nil {
+		sql += " offset " + offset.String()
+	}
+	return sql
+}
+
+func (s *CompoundSelectStatement) String() string {
+	sql := s.Selects[0].operandString(s)
+	for i, op := range s.Ops {
+		sql += " " + op.Op.Value
+		if op.All {
+			sql += " all"
+		}
+		sql += " " + s.Selects[i+1].operandString(s)
+	}
+	return sql + orderByString(s.OrderBy, s.Limit, s.Offset)
+}
+
+// operandString 在需要时给操作数加上括号：带 order by 或 limit 的 select
+// 不加括号会被当成整个集合运算的子句；嵌套的集合运算只有在
+// intersect 出现在 union 或 except 里时才能省略括号
+func (o *SetOperand) operandString(parent *CompoundSelectStatement) string {
+	if o.Select != nil {
+		if o.Select.OrderBy != nil || o.Select.Limit != nil || o.Select.Offset != nil {
+			return "(" + o.Select.String() + ")"
+		}
+		return o.Select.String()
+	}
+
+	c := o.Compound
+	if isIntersect(c) && !isIntersect(parent) && c.OrderBy == nil && c.Limit == nil && c.Offset == nil {
+		return c.String()
+	}
+	return "(" + c.String() + ")"
+}
+
+func isIntersect(c *CompoundSelectStatement) bool {
+	return c.Ops[0].Op.Value == string(IntersectKeyword)
+}
+
+func (i *OrderByItem) String() string {
+	if !i.Asc {
+		return i.Exp.String() + " desc"
+	}
+	return i.Exp.String()
+}
+
+// 内连接输出成 join，外连接省略 outer，比如 left join
+func (j *Join) String() string {
+	sql := "join " + j.Table.String() + " on " + j.On.String()
+	if j.Kind == InnerJoin {
+		return sql
+	}
+	return j.Kind.String() + " " + sql
+}
+
+func (r *TableReference) String() string {
+	sql := ""
+	switch {
+	case r.Select != nil:
+		sql = "(" + r.Select.String() + ")"
+	case r.Values != nil:
+		sql = "(" + r.Values.String() + ")"
+	default:
+		sql = quoteIdentifier(r.Name.Value)
+	}
+	if r.Alias != nil {
+		sql += " as " + quoteIdentifier(r.Alias.Value)
+	}
+	if r.Cols != nil {
+		sql += "(" + identifierList(r.Cols) + ")"
+	}
+	return sql
+}
+
+func (i *SelectItem) String() string {
+	if i.Asterisk {
+		return "*"
+	}
+	if i.As != nil {
+		return i.Exp.String() + " as " + quoteIdentifier(i.As.Value)
+	}
+	return i.Exp.String()
+}
+
+func (s *InsertStatement) String() string {
+	sql := "insert into " + quoteIdentifier(s.Table.Value)
+	if s.Cols != nil {
+		sql += " (" + identifierList(s.Cols) + ")"
+	}
+	if s.Select != nil {
+		return sql + " " + s.Select.String()
+	}
+	return sql + " " + valuesString(s.Values)
+}
+
+func (s *ValuesStatement) String() string {
+	return valuesString(s.Rows)
+}
+
+func valuesString(rows [][]*Expression) string {
+	items := make([]string, len(rows))
+	for i, row := range rows {
+		items[i] = "(" + expressionList(row) + ")"
+	}
+	return "values " + strings.Join(items, ", ")
+}
+
+func (s *CreateIndexStatement) String() string {
+	sql := "create "
+	if s.Unique {
+		sql += "unique "
+	}
+	return sql + "index " + quoteIdentifier(s.Name.Value) + " on " + quoteIdentifier(s.Table.Value) + " (" + identifierList(s.Cols) + ")"
+}
+
+func (s *CreateViewStatement) String() string {
+	sql := "create "
+	if s.OrReplace {
+		sql += "or replace "
+	}
+	return sql + "view " + quoteIdentifier(s.Name.Value) + " as " + s.Select.String()
+}
+
+func (s *DropViewStatement) String() string {
+	return "drop view " + quoteIdentifier(s.Name.Value)
+}
+
+func (s *DropIndexStatement) String() string {
+	sql := "drop index "
+	if s.IfExists {
+		sql += "if exists "
+	}
+	sql += quoteIdentifier(s.Name.Value)
+	if s.Table != nil {
+		sql += " on " + quoteIdentifier(s.Table.Value)
+	}
+	return sql
+}
+
+func (s *DeleteStatement) String() string {
+	sql := "delete from " + quoteIdentifier(s.From.Value)
+	if s.Where != nil {
+		sql += " where " + s.Where.String()
+	}
+	return sql
+}
+
+func (s *ExplainStatement) String() string {
+	if s.Analyze {
+		return "explain analyze " + s.Statement.String()
+	}
+	return "explain " + s.Statement.String()
+}
+
+func (s *ShowStatement) String() string {
+	return "show " + s.Target.String()
+}
+
+func (s *DescribeStatement) String() string {
+	return "describe " + quoteIdentifier(s.Table.Value)
+}
+
+func (s *TruncateStatement) String() string {
+	return "truncate table " + identifierList(s.Tables)
+}
+
+func (s *UpdateStatement) String() string {
+	set := make([]string, len(s.Set))
+	for i, a := range s.Set {
+		set[i] = a.String()
+	}
+	sql := "update " + quoteIdentifier(s.Table.Value) + " set " + strings.Join(set, ", ")
+	if s.Where != nil {
+		sql += " where " + s.Where.String()
+	}
+	return sql
+}
+
+func (a *Assignment) String() string {
+	return quoteIdentifier(a.Column.Value) + " = " + a.Value.String()
+}
+
+func (s *CreateTableStatement) String() string {
+	cols := make([]string, len(s.Cols))
+	for i, col := range s.Cols {
+		cols[i] = col.String()
+	}
+	if s.PrimaryKey != nil {
+		cols = append(cols, "primary key ("+identifierList(s.PrimaryKey)+")")
+	}
+	for _, unique := range s.UniqueConstraints {
+		cols = append(cols, "unique ("+identifierList(unique)+")")
+	}
+	for _, fk := range s.ForeignKeys {
+		cols = append(cols, "foreign key ("+identifierList(fk.Cols)+") "+fk.String())
+	}
+	for _, check := range s.Checks {
+		cols = append(cols, "check ("+check.String()+")")
+	}
+	sql := "create table "
+	if s.IfNotExists {
+		sql += "if not exists "
+	}
+	return sql + quoteIdentifier(s.Name.Value) + " (" + strings.Join(cols, ", ") + ")"
+}
+
+func (c *ColumnDefinition) String() string {
+	sql := quoteIdentifier(c.Name.Value) + " " + c.Datatype.Value
+	if len(c.Params) > 0 {
+		params := make([]string, len(c.Params))
+		for i, p := range c.Params {
+			params[i] = p.Value
+		}
+		sql += "(" + strings.Join(params, ", ") + ")"
+	}
+	if c.Default != nil {
+		sql += " default " + c.Default.String()
+	}
+	if c.PrimaryKey {
+		sql += " primary key"
+	}
+	if c.NotNull {
+		sql += " not null"
+	}
+	if c.Unique {
+		sql += " unique"
+	}
+	for _, check := range c.Checks {
+		sql += " check (" + check.String() + ")"
+	}
+	if c.References != nil {
+		sql += " " + c.References.String()
+	}
+	return sql
+}
+
+// String 只输出 references 子句，引用方的列由调用方决定怎么写
+func (fk *ForeignKey) String() string {
+	sql := "references " + quoteIdentifier(fk.Table.Value)
+	if fk.RefCols != nil {
+		sql += " (" + identifierList(fk.RefCols) + ")"
+	}
+	return sql
+}
+
+func (e *Expression) String() string {
+	switch e.Kind {
+	case LiteralKind:
+		return literalString(e.Literal)
+	case BinaryKind:
+		return e.Binary.String()
+	case UnaryKind:
+		return e.Unary.String()
+	case FunctionCallKind:
+		return e.Call.String()
+	case CaseKind:
+		return e.Case.String()
+	case CastKind:
+		return e.Cast.String()
+	case InKind:
+		return e.In.String()
+	case BetweenKind:
+		return e.Between.String()
+	case LikeKind:
+		return e.Like.String()
+	case IsNullKind:
+		return e.IsNull.String()
+	case ExistsKind:
+		return e.Exists.String()
+	case ColumnKind:
+		return e.Column.String()
+	case TypedLiteralKind:
+		return e.TypedLiteral.String()
+	}
+	return ""
+}
+
+func (e *InExpression) String() string {
+	sql := operandString(e.Left, comparisonPrecedence, false)
+	if e.Not {
+		sql += " not"
+	}
+	if e.Select != nil {
+		return sql + " in (" + e.Select.String() + ")"
+	}
+	return sql + " in (" + expressionList(e.List) + ")"
+}
+
+// 两端按比较运算符的右操作数处理，里面的比较和 and 都要加括号
+func (e *BetweenExpression) String() string {
+	sql := operandString(e.Exp, comparisonPrecedence, false)
+	if e.Not {
+		sql += " not"
+	}
+	return sql + " between " + operandString(e.Low, comparisonPrecedence, true) +
+		" and " + operandString(e.High, comparisonPrecedence, true)
+}
+
+func (e *LikeExpression) String() string {
+	sql := operandString(e.Exp, comparisonPrecedence, false)
+	if e.Not {
+		sql += " not"
+	}
+	sql += " like " + operandString(e.Pattern, comparisonPrecedence, true)
+	if e.Escape != nil {
+		sql += " escape " + operandString(e.Escape, comparisonPrecedence, true)
+	}
+	return sql
+}
+
+func (e *IsNullExpression) String() string {
+	sql := operandString(e.Exp, comparisonPrecedence, false)
+	if e.Not {
+		return sql + " is not null"
+	}
+	return sql + " is null"
+}
+
+func (c *ColumnReference) String() string {
+	if c.Qualifier != nil {
+		return quoteIdentifier(c.Qualifier.Value) + "." + quoteIdentifier(c.Name.Value)
+	}
+	return quoteIdentifier(c.Name.Value)
+}
+
+func (e *TypedLiteralExpression) String() string {
+	return e.Type.Value + " " + literalString(&e.Value)
+}
+
+func (e *ExistsExpression) String() string {
+	return "exists (" + e.Select.String() + ")"
+}
+
+// :: 和 cast() 是同一个节点，统一输出成 cast()，不用考虑优先级
+func (e *CastExpression) String() string {
+	return "cast(" + e.Exp.String() + " as " + e.Type.Value + ")"
+}
+
+func (e *CaseExpression) String() string {
+	sql := "case"
+	if e.Operand != nil {
+		sql += " " + e.Operand.String()
+	}
+	for _, w := range e.Whens {
+		sql += " when " + w.When.String() + " then " + w.Then.String()
+	}
+	if e.Else != nil {
+		sql += " else " + e.Else.String()
+	}
+	return sql + " end"
+}
+
+func (e *FunctionCallExpression) String() string {
+	name := quoteIdentifier(e.Name.Value)
+	if e.Asterisk {
+		return name + "(*)"
+	}
+	if e.Distinct {
+		return name + "(distinct " + expressionList(e.Args) + ")"
+	}
+	return name + "(" + expressionList(e.Args) + ")"
+}
+
+// 运算符两边总是有空格，避免 a - -1 变成注释 a--1。
+// 只在重新解析会改变结构时才给子表达式加括号。
+func (e *BinaryExpression) String() string {
+	precedence := binaryPrecedence(&e.Op)
+	return operandString(e.A, precedence, false) + " " + e.Op.Value + " " + operandString(e.B, precedence, true)
+}
+
+func (e *UnaryExpression) String() string {
+	operand := operandString(e.Operand, unaryOperatorPrecedence(e), false)
+	// 前缀运算符连在一起时不需要括号，但 - -a 不能写成注释 --a
+	if e.Operand.Kind == UnaryKind {
+		operand = e.Operand.String()
+	}
+	// 符号紧挨着数字时会被 FoldSign 折叠进字面量，所以 - 5 和 - 5::int 要保留空格
+	if e.Op.Kind == KeywordKind || strings.IndexAny(operand[:1], "+-.0123456789") == 0 {
+		return e.Op.Value + " " + operand
+	}
+	return e.Op.Value + operand
+}
+
+// operandString 输出优先级为 precedence 的运算符的操作数，right 表示右操作数
+func operandString(e *Expression, precedence uint, right bool) string {
+	s := e.String()
+	switch e.Kind {
+	case BinaryKind:
+		p := binaryPrecedence(&e.Binary.Op)
+		if p < precedence || (p == precedence && right) {
+			return "(" + s + ")"
+		}
+	case UnaryKind:
+		// 前缀运算符会吞掉后面所有优先级更高的运算符
+		if unaryOperatorPrecedence(e.Unary) <= precedence {
+			return "(" + s + ")"
+		}
+	case InKind, BetweenKind, LikeKind, IsNullKind:
+		// 谓词和比较运算符同级，同样左结合
+		if comparisonPrecedence < precedence || (comparisonPrecedence == precedence && right) {
+			return "(" + s + ")"
+		}
+	}
+	return s
+}
+
+func unaryOperatorPrecedence(e *UnaryExpression) uint {
+	if e.Op.Kind == KeywordKind {
+		return notPrecedence
+	}
+	return unaryPrecedence
+}
+
+func expressionList(exps []*Expression) string {
+	items := make([]string, len(exps))
+	for i, exp := range exps {
+		items[i] = exp.String()
+	}
+	return strings.Join(items, ", ")
+}
+
+func identifierList(names []Token) string {
+	items := make([]string, len(names))
+	for i, name := range names {
+		items[i] = quoteIdentifier(name.Value)
+	}
+	return strings.Join(items, ", ")
+}
+
+func literalString(t *Token) string {
+	switch t.Kind {
+	case IdentifierKind:
+		return quoteIdentifier(t.Value)
+	case StringKind:
+		return "'" + strings.ReplaceAll(t.Value, "'", "''") + "'"
+	case NamedParameterKind:
+		return ":" + t.Value
+	}
+	return t.Value
+}
+
+// quoteIdentifier 在标识符会被词法分析器读成别的东西时加上双引号：
+// 包含大写字母或特殊字符、和关键字或布尔值同名、不以字母开头。
+func quoteIdentifier(name string) string {
+	if !needsQuotes(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func needsQuotes(name string) bool {
+	if name == "" || reservedWords[name] {
+		return true
+	}
+
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			return true
+		}
+		if unicode.IsLetter(r) {
+			continue
+		}
+		if i > 0 && (unicode.IsDigit(r) || r == '_' || r == '$') {
+			continue
+		}
+		return true
+	}
+	return !utf8.ValidString(name)
+}
+
+// reservedWords 是不加引号就不会被读成标识符的单词
+var reservedWords = func() map[string]bool {
+	words := map[string]bool{"true": true, "false": true}
+	for _, k := range keywords {
+		words[string(k)] = true
+	}
+	return words
+}()
@@ -0,0 +1,278 @@
+package gosql
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stripPositions 清除语法树中所有 token 的 Raw 和位置信息，
+// 只保留 Value 和 Kind，同时清除节点的 Start 和 End，
+// 用来比较同一语义、不同写法的语法树。
+func stripPositions(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			stripPositions(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			stripPositions(v.Index(i))
+		}
+	case reflect.Struct:
+		if t, ok := v.Addr().Interface().(*Token); ok {
+			*t = Token{Value: t.Value, Kind: t.Kind}
+			return
+		}
+		if l, ok := v.Addr().Interface().(*Location); ok {
+			*l = Location{}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			stripPositions(v.Field(i))
+		}
+	}
+}
+
+func TestAst_String(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{
+			source: "SELECT A,   b FROM   T",
+			want:   "select a, b from t;",
+		},
+		{
+			source: "select 'it''s', -5, +2, 1_000, 0x1F, TRUE, NULL, $1, :Name, ? from t",
+			want:   "select 'it''s', -5, 2, 1000, 0x1F, true, null, $1, :Name, ? from t;",
+		},
+		{
+			source: `select "Mixed", "select", "a b", "x""y", "_u", "ok" from "T"`,
+			want:   `select "Mixed", "select", "a b", "x""y", "_u", ok from "T";`,
+		},
+		{
+			source: "create table users (id INT, name text); insert into users values (1, $$O'Brien$$)",
+			want:   "create table users (id int, name text);\ninsert into users values (1, 'O''Brien');",
+		},
+		{
+			source: "create table t (id INT NOT NULL PRIMARY KEY, name text NULL)",
+			want:   "create table t (id int primary key not null, name text);",
+		},
+		{
+			source: "INSERT INTO t VALUES (1,'a'),(2,'b') , (3,'c')",
+			want:   "insert into t values (1, 'a'), (2, 'b'), (3, 'c');",
+		},
+		{
+			source: "select E'a\\nb' from t",
+			want:   "select 'a\nb' from t;",
+		},
+		{
+			source: "select a=1 AND b>2 OR NOT c, 1+2*3, a- -1, - -a from t",
+			want:   "select a = 1 and b > 2 or not c, 1 + 2 * 3, a - -1, - -a from t;",
+		},
+		{
+			source: "select ((1)), (a + b) * c, a + (b * c) from t",
+			want:   "select 1, (a + b) * c, a + b * c from t;",
+		},
+		{
+			source: `select *,"*" from t`,
+			want:   `select *, "*" from t;`,
+		},
+		{
+			source: `select id ident, name AS "Name" from t`,
+			want:   `select id as ident, name as "Name" from t;`,
+		},
+		{
+			source: "select * from a INNER JOIN b on x LEFT OUTER JOIN c on y full outer join d on z right join e on w",
+			want:   "select * from a join b on x left join c on y full join d on z right join e on w;",
+		},
+		{
+			source: "BEGIN WORK; COMMIT TRANSACTION; rollback",
+			want:   "begin;\ncommit;\nrollback;",
+		},
+		{
+			source: "",
+			want:   "",
+		},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse(test.source)
+		assert.Nil(t, err, test.source)
+		assert.Equal(t, test.want, ast.String(), test.source)
+	}
+}
+
+func TestAst_StringRoundTrip(t *testing.T) {
+	corpus := []string{
+		"select a from t",
+		"select a, b, c from t; select d from u;",
+		"SELECT Name, 'Ada', 42, -3.5, .5, 5., 1e10, 2E-3, 0xff from Users",
+		"select 'it''s', 'say \"hi\"', '', '--not a comment', '/* nor this */' from t",
+		`select "Upper", "with space", "quote""d", "from", "true", "é", "Ünïcode" from "Table"`,
+		"select true, false, null, $1, $22, ?, :param from t",
+		"insert into t values (1, 'one', -1, true, null)",
+		"insert into \"T\" values ('a''b''c')",
+		"insert into users (id, \"Name\") values (1, 'Ada')",
+		"insert into t values (1, 'a'), (2, 'b'), (3, 'c')",
+		"insert into users (id) values (1), (-2), ($1)",
+		"insert into archive select * from events where ts < 100",
+		"insert into archive (id, name) select id, name from users u where id > 1",
+		"create table t (a int, b text)",
+		"create table events (created timestamp not null, day date, at time default time '09:00:00')",
+		"select date '2024-01-15', time '10:30:00.25', timestamp '2024-01-15 10:30:00', date '2024-01-15'::text from t where created > timestamp '2024-01-01 00:00:00'",
+		"create table t (a boolean, b real, c float(53), d numeric(10, 2), e decimal, f varchar(255) not null, g char(1), h bigint)",
+		"create table if not exists t (a int)",
+		"create table t (id int primary key, name text)",
+		"create table t (id int primary key not null, name text not null, note text)",
+		"create table t (created int default 0, status text default 'new' not null, ttl int default 60 * 60, n int default -1)",
+		"create table t (age int not null check (age > 0) check (age < 200), a int, b int, check (a < b or b = 0), check (a <> 0))",
+		"create table orders (id int primary key, user_id int not null references users (id), sku text references \"Items\")",
+		"create table t (a int, b int, foreign key (a, b) references u (x, y), foreign key (b) references v)",
+		"create table t (id int not null unique, a int, b int, unique (a, b), unique (b), primary key (id))",
+		"create table t (id int, name text, primary key (id, \"Name\"))",
+		"create table \"if\" (\"exists\" text)",
+		"create table \"Order\" (\"Key\" int, \"select\" text)",
+		"select e'tab\\there', $tag$dollar 'quoted'$tag$ from t",
+		"select über, naïve, 名前 from 表",
+		"select a = 1 and b <> 'x' or not c, 1 + 2 * 3 - 4 / 5 % 6, 'a' || 'b' from t",
+		"select - -a, -a * b, -a || b, not not a, a - -1, 1 - +2 from t",
+		"select - 5",
+		"select -(5)",
+		"select 1 - - 5",
+		"select - - 5, + .5, - 5::text, -(5)::int, -(-5)",
+		"insert into t values (1 + 2, 'x' || 'y', -a)",
+		"select (a or b) and c, (1 + 2) * 3, 1 - (2 - 3), ((1)), -(a + b), (-a) || b from t",
+		"select not (a and b), (not a) = b, a || (-b), (a = b) = c from t",
+		"select a from t where a = 1 and (b = 'x' or c <> $1)",
+		"select a, b from t where a > 1 order by a desc, b, a + b desc",
+		"select a from t order by a limit 10 offset 20",
+		"select dept, salary from emp where salary > 0 group by dept, salary / 1000 having dept <> 'x' order by dept limit 5",
+		"select 1 from t having 1 = 1",
+		"select distinct city, \"distinct\" from users",
+		"select distinct * from t",
+		"select count(*), count(distinct a), sum(a + b) * 2, -avg(x), max(min(y)) from t group by z having count(*) > 1",
+		"select case when score > 90 then 'A' when score > 80 then 'B' else 'F' end as grade, case status when 1 then 'new' end from t",
+		"select case when a then case b when 1 then 'x' else 'y' end end * 2, -case when a then 1 end from t",
+		"select cast('5' as int), x::text::int, -5::text, -a::int, (a + b)::int, a + b::int from t",
+		"select upper(trim(name)), coalesce(a, b, 0), now(), \"Weird Fn\"(1), length(s) > 3 from t where lower(email) = $1",
+		"select a from t limit $1 offset :skip",
+		"values (1, 'a'), (2 + 3, null)",
+		"truncate table a, \"B\"",
+		"begin; update t set a = 1; commit; begin; rollback",
+		"show tables; explain show tables",
+		"describe users; desc \"Users\"; select a from t order by a desc",
+		"create view v as select a, b from t where a in (select 1) order by b; create or replace view \"V\" as select 1; drop view v",
+		"drop index i; drop index if exists \"I\" on \"Users\"",
+		"explain select a from t where id = 1; explain analyze insert into t values (1); explain begin",
+		"select v.id from (values (1, 'a'), (2, 'b')) as v(id, \"Name\") join (select 1) as s on true join (values (1)) as w on true",
+		"select a from t1 union all select a from t2 where a > 0 except select 1 order by a desc limit 3",
+		"select 1 union select 2 intersect select 3",
+		"(select 1 union select 2) intersect (select 3 except select 4) intersect all select 5",
+		"(select 1 intersect select 2 limit 1) union (select a from t order by a offset 2) union (select 3 union select 4)",
+		"select 1; (select 2 order by 1)",
+		"select * from a left join b on a.id = b.a_id join c on true right join d on d.id = c.id full join e on 1 = 1",
+		"select u.name, \"O\".total from users as u join orders as \"O\" on u.id = \"O\".user_id join (select id from items) as i on i.id = \"O\".item_id where u.id > 0",
+		"select total from (select sum(x) as total from (select x from nums where x in (select 1)) as \"Inner\") as t where total > 0",
+		"select exists (select 1 from orders where user_id = 7 limit 1), not exists (select 1) from users where a or not exists (select * from t where b in (select c from u))",
+		"select (a + b) is null, a = b is not null, (a is null) is null from t where deleted_at is null and not (b is not null)",
+		"select name like 'A%' and code not like '100\\%' escape '\\', (a like b) like c, a like (b = c) from t where lower(name) like $1",
+		"select a between 1 and 2 and b not between c - 1 and c + 1, a between (b and c) and (d = e), (a between 1 and 2) between 3 and 4 from t where price between $1 and $2",
+		"select a in (1, 2), b not in ('x') from t where id in (select user_id from orders where total > 10) and c not in (select 1)",
+		"delete from t",
+		"create index i on t (b, a)",
+		"create unique index \"Idx\" on users (email, \"Name\", id)",
+		"update t set a = 1",
+		"update \"Users\" set name = 'Ada', age = age + 1, score = (a + b) * 2 where id = 3 and not banned",
+		"delete from \"Users\" where id = 5 or (name = 'x' and not active)",
+		`select *, "*", a * b from t`,
+		`select id as identifier, name n, a + 1 as "Total", b "from" from t`,
+		`select id from users u where id = 1`,
+		`select 1 + 1, 'a' || 'b' as ab`,
+		`select id from users as "where"`,
+	}
+
+	for _, source := range corpus {
+		ast, err := Parse(source)
+		assert.Nil(t, err, source)
+
+		reparsed, err := Parse(ast.String())
+		assert.Nil(t, err, ast.String())
+
+		stripPositions(reflect.ValueOf(ast))
+		stripPositions(reflect.ValueOf(reparsed))
+		assert.Equal(t, ast, reparsed, source)
+	}
+}
+
+func TestExpression_StringParentheses(t *testing.T) {
+	binary := func(op string, a, b *Expression) *Expression {
+		kind := OperatorKind
+		if op == "and" || op == "or" {
+			kind = KeywordKind
+		}
+		return &Expression{
+			Binary: &BinaryExpression{A: a, B: b, Op: *tok(op, kind, 0)},
+			Kind:   BinaryKind,
+		}
+	}
+	unary := func(op string, operand *Expression) *Expression {
+		kind := OperatorKind
+		if op == "not" {
+			kind = KeywordKind
+		}
+		return &Expression{
+			Unary: &UnaryExpression{Op: *tok(op, kind, 0), Operand: operand},
+			Kind:  UnaryKind,
+		}
+	}
+	one, two, three := literal("1", NumericKind, 0), literal("2", NumericKind, 0), literal("3", NumericKind, 0)
+	a, b := columnRef("a", 0), columnRef("b", 0)
+
+	tests := []struct {
+		exp  *Expression
+		want string
+	}{
+		{binary("*", binary("+", one, two), three), "(1 + 2) * 3"},
+		{binary("+", one, binary("*", two, three)), "1 + 2 * 3"},
+		{binary("-", one, binary("-", two, three)), "1 - (2 - 3)"},
+		{binary("-", binary("-", one, two), three), "1 - 2 - 3"},
+		{binary("and", binary("or", a, b), a), "(a or b) and a"},
+		{unary("not", binary("and", a, b)), "not (a and b)"},
+		{binary("=", unary("not", a), b), "(not a) = b"},
+		{binary("||", unary("-", a), b), "(-a) || b"},
+		{binary("||", a, unary("-", b)), "a || (-b)"},
+		{unary("-", binary("+", a, b)), "-(a + b)"},
+		{unary("-", unary("-", a)), "- -a"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.want, test.exp.String())
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"users", "users"},
+		{"user_id", "user_id"},
+		{"a1$", "a1$"},
+		{"名前", "名前"},
+		{"Users", `"Users"`},
+		{"select", `"select"`},
+		{"false", `"false"`},
+		{"1a", `"1a"`},
+		{"_a", `"_a"`},
+		{"a-b", `"a-b"`},
+		{`a"b`, `"a""b"`},
+		{"", `""`},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.want, quoteIdentifier(test.name), test.name)
+	}
+}
@@ -3,42 +3,278 @@ package gosql
 import (
 	"fmt"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 //词法分析器的全部内容
 
 type Location struct {
-	Line uint
-	Col  uint
+	Line uint `json:"line"`
+	Col  uint `json:"col"`
 }
 
 type Keyword string
 
+func (k Keyword) String() string {
+	return string(k)
+}
+
 const (
-	SelectKeyword Keyword = "select"
-	FromKeyword   Keyword = "from"
-	AsKeyword     Keyword = "as"
-	TableKeyword  Keyword = "table"
-	CreateKeyword Keyword = "create"
-	InsertKeyword Keyword = "insert"
-	IntoKeyword   Keyword = "into"
-	ValuesKeyword Keyword = "values"
-	IntKeyword    Keyword = "int"
-	TextKeyword   Keyword = "text"
-	WhereKeyword  Keyword = "where"
+	SelectKeyword      Keyword = "select"
+	FromKeyword        Keyword = "from"
+	AsKeyword          Keyword = "as"
+	TableKeyword       Keyword = "table"
+	CreateKeyword      Keyword = "create"
+	InsertKeyword      Keyword = "insert"
+	IntoKeyword        Keyword = "into"
+	ValuesKeyword      Keyword = "values"
+	IntKeyword         Keyword = "int"
+	TextKeyword        Keyword = "text"
+	WhereKeyword       Keyword = "where"
+	AndKeyword         Keyword = "and"
+	OrKeyword          Keyword = "or"
+	NotKeyword         Keyword = "not"
+	NullKeyword        Keyword = "null"
+	OrderKeyword       Keyword = "order"
+	ByKeyword          Keyword = "by"
+	GroupKeyword       Keyword = "group"
+	HavingKeyword      Keyword = "having"
+	LimitKeyword       Keyword = "limit"
+	OffsetKeyword      Keyword = "offset"
+	AscKeyword         Keyword = "asc"
+	DescKeyword        Keyword = "desc"
+	JoinKeyword        Keyword = "join"
+	InnerKeyword       Keyword = "inner"
+	LeftKeyword        Keyword = "left"
+	RightKeyword       Keyword = "right"
+	FullKeyword        Keyword = "full"
+	OuterKeyword       Keyword = "outer"
+	CrossKeyword       Keyword = "cross"
+	OnKeyword          Keyword = "on"
+	UsingKeyword       Keyword = "using"
+	IfKeyword          Keyword = "if"
+	ExistsKeyword      Keyword = "exists"
+	PrimaryKeyword     Keyword = "primary"
+	KeyKeyword         Keyword = "key"
+	UniqueKeyword      Keyword = "unique"
+	DefaultKeyword     Keyword = "default"
+	CheckKeyword       Keyword = "check"
+	ForeignKeyword     Keyword = "foreign"
+	ReferencesKeyword  Keyword = "references"
+	DeleteKeyword      Keyword = "delete"
+	UpdateKeyword      Keyword = "update"
+	SetKeyword         Keyword = "set"
+	IndexKeyword       Keyword = "index"
+	DistinctKeyword    Keyword = "distinct"
+	CaseKeyword        Keyword = "case"
+	WhenKeyword        Keyword = "when"
+	ThenKeyword        Keyword = "then"
+	ElseKeyword        Keyword = "else"
+	EndKeyword         Keyword = "end"
+	CastKeyword        Keyword = "cast"
+	InKeyword          Keyword = "in"
+	BetweenKeyword     Keyword = "between"
+	LikeKeyword        Keyword = "like"
+	EscapeKeyword      Keyword = "escape"
+	IsKeyword          Keyword = "is"
+	UnionKeyword       Keyword = "union"
+	IntersectKeyword   Keyword = "intersect"
+	ExceptKeyword      Keyword = "except"
+	AllKeyword         Keyword = "all"
+	TruncateKeyword    Keyword = "truncate"
+	BeginKeyword       Keyword = "begin"
+	CommitKeyword      Keyword = "commit"
+	RollbackKeyword    Keyword = "rollback"
+	TransactionKeyword Keyword = "transaction"
+	WorkKeyword        Keyword = "work"
+	ExplainKeyword     Keyword = "explain"
+	AnalyzeKeyword     Keyword = "analyze"
+	ShowKeyword        Keyword = "show"
+	TablesKeyword      Keyword = "tables"
+	DescribeKeyword    Keyword = "describe"
+	ViewKeyword        Keyword = "view"
+	ReplaceKeyword     Keyword = "replace"
+	DropKeyword        Keyword = "drop"
+	BooleanKeyword     Keyword = "boolean"
+	RealKeyword        Keyword = "real"
+	FloatKeyword       Keyword = "float"
+	NumericKeyword     Keyword = "numeric"
+	DecimalKeyword     Keyword = "decimal"
+	VarcharKeyword     Keyword = "varchar"
+	CharKeyword        Keyword = "char"
+	BigintKeyword      Keyword = "bigint"
+	DateKeyword        Keyword = "date"
+	TimeKeyword        Keyword = "time"
+	TimestampKeyword   Keyword = "timestamp"
 )
 
 type Symbol string
 
+func (s Symbol) String() string {
+	return string(s)
+}
+
 const (
-	SemicolonSymbol  Symbol = ";"
-	AsteriskSymbol   Symbol = "*"
-	CommaSymbol      Symbol = ","
-	LeftParenSymbol  Symbol = "("
-	RightParenSymbol Symbol = ")"
-	ConcatSymbol     Symbol = "||"
+	SemicolonSymbol       Symbol = ";"
+	AsteriskSymbol        Symbol = "*"
+	CommaSymbol           Symbol = ","
+	LeftParenSymbol       Symbol = "("
+	RightParenSymbol      Symbol = ")"
+	ConcatSymbol          Symbol = "||"
+	EqSymbol              Symbol = "="
+	NeqSymbol             Symbol = "<>"
+	AltNeqSymbol          Symbol = "!="
+	LtSymbol              Symbol = "<"
+	GtSymbol              Symbol = ">"
+	LteSymbol             Symbol = "<="
+	GteSymbol             Symbol = ">="
+	PlusSymbol            Symbol = "+"
+	MinusSymbol           Symbol = "-"
+	SlashSymbol           Symbol = "/"
+	ModuloSymbol          Symbol = "%"
+	CastSymbol            Symbol = "::"
+	JSONExtractSymbol     Symbol = "->"
+	JSONExtractTextSymbol Symbol = "->>"
+	DotSymbol             Symbol = "."
+)
+
+// 词法分析器能识别的全部关键字
+var keywords = []Keyword{
+	SelectKeyword,
+	InsertKeyword,
+	ValuesKeyword,
+	TableKeyword,
+	CreateKeyword,
+	WhereKeyword,
+	FromKeyword,
+	IntoKeyword,
+	TextKeyword,
+	AsKeyword,
+	IntKeyword,
+	AndKeyword,
+	OrKeyword,
+	NotKeyword,
+	NullKeyword,
+	OrderKeyword,
+	ByKeyword,
+	GroupKeyword,
+	HavingKeyword,
+	LimitKeyword,
+	OffsetKeyword,
+	AscKeyword,
+	DescKeyword,
+	JoinKeyword,
+	InnerKeyword,
+	LeftKeyword,
+	RightKeyword,
+	FullKeyword,
+	OuterKeyword,
+	CrossKeyword,
+	OnKeyword,
+	UsingKeyword,
+	IfKeyword,
+	ExistsKeyword,
+	PrimaryKeyword,
+	KeyKeyword,
+	UniqueKeyword,
+	DefaultKeyword,
+	CheckKeyword,
+	ForeignKeyword,
+	ReferencesKeyword,
+	DeleteKeyword,
+	UpdateKeyword,
+	SetKeyword,
+	IndexKeyword,
+	DistinctKeyword,
+	CaseKeyword,
+	WhenKeyword,
+	ThenKeyword,
+	ElseKeyword,
+	EndKeyword,
+	CastKeyword,
+	InKeyword,
+	BetweenKeyword,
+	LikeKeyword,
+	EscapeKeyword,
+	IsKeyword,
+	UnionKeyword,
+	IntersectKeyword,
+	ExceptKeyword,
+	AllKeyword,
+	TruncateKeyword,
+	BeginKeyword,
+	CommitKeyword,
+	RollbackKeyword,
+	TransactionKeyword,
+	WorkKeyword,
+	ExplainKeyword,
+	AnalyzeKeyword,
+	ShowKeyword,
+	TablesKeyword,
+	DescribeKeyword,
+	ViewKeyword,
+	ReplaceKeyword,
+	DropKeyword,
+	BooleanKeyword,
+	RealKeyword,
+	FloatKeyword,
+	NumericKeyword,
+	DecimalKeyword,
+	VarcharKeyword,
+	CharKeyword,
+	BigintKeyword,
+	DateKeyword,
+	TimeKeyword,
+	TimestampKeyword,
+}
+
+// 应该保留的语法
+var symbols = []Symbol{
+	CommaSymbol,
+	LeftParenSymbol,
+	RightParenSymbol,
+	SemicolonSymbol,
+	AsteriskSymbol,
+	ConcatSymbol,
+	EqSymbol,
+	NeqSymbol,
+	AltNeqSymbol,
+	LtSymbol,
+	GtSymbol,
+	LteSymbol,
+	GteSymbol,
+	PlusSymbol,
+	MinusSymbol,
+	SlashSymbol,
+	ModuloSymbol,
+	CastSymbol,
+	JSONExtractSymbol,
+	JSONExtractTextSymbol,
+	DotSymbol,
+}
+
+var (
+	keywordMatcher = newMatcher(keywordOptions(), true)
+	symbolMatcher  = newMatcher(symbolOptions(), false)
 )
 
+func keywordOptions() []string {
+	options := make([]string, 0, len(keywords))
+	for _, k := range keywords {
+		options = append(options, string(k))
+	}
+	return options
+}
+
+func symbolOptions() []string {
+	options := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		options = append(options, string(s))
+	}
+	return options
+}
+
 type TokenKind uint
 
 const (
@@ -48,12 +284,87 @@ const (
 	StringKind
 	NumericKind
 	BoolKind
+	ParameterKind
+	NamedParameterKind
+	// = < + * || :: 这类运算符，; , ( ) 仍然是 SymbolKind
+	OperatorKind
+	// 只有 LexWithTrivia 才会返回下面两种 token
+	WhitespaceKind
+	CommentKind
 )
 
+var tokenKindNames = map[TokenKind]string{
+	KeywordKind:        "keyword",
+	SymbolKind:         "symbol",
+	IdentifierKind:     "identifier",
+	StringKind:         "string",
+	NumericKind:        "numeric",
+	BoolKind:           "bool",
+	ParameterKind:      "parameter",
+	NamedParameterKind: "named parameter",
+	OperatorKind:       "operator",
+	WhitespaceKind:     "whitespace",
+	CommentKind:        "comment",
+}
+
+func (k TokenKind) String() string {
+	if name, ok := tokenKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("TokenKind(%d)", uint(k))
+}
+
+func (k TokenKind) MarshalText() ([]byte, error) {
+	if name, ok := tokenKindNames[k]; ok {
+		return []byte(name), nil
+	}
+	return nil, fmt.Errorf("unknown token kind %d", uint(k))
+}
+
+func (k *TokenKind) UnmarshalText(text []byte) error {
+	for kind, name := range tokenKindNames {
+		if name == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown token kind %q", text)
+}
+
+// Token 覆盖源码中 [Pos, EndPos) 的字节范围，
+// Loc 是第一个字符的位置，End 是最后一个字符之后的位置。
+// 引号、前缀等语法字符也算在范围内。
+//
+// Value 是规范化后的值（关键字和不带引号的标识符转成小写，
+// 字符串去掉引号），Raw 是源码中的原始文本。
 type Token struct {
-	Value string
-	Kind  TokenKind
-	Loc   Location
+	Value  string    `json:"value"`
+	Raw    string    `json:"raw"`
+	Kind   TokenKind `json:"kind"`
+	Loc    Location  `json:"loc"`
+	End    Location  `json:"end"`
+	Pos    uint      `json:"pos"`
+	EndPos uint      `json:"endPos"`
+}
+
+// String 形如 keyword("select") @1:1，行列从 1 开始
+func (t *Token) String() string {
+	return fmt.Sprintf("%s(%q) @%d:%d", t.Kind, t.Value, t.Loc.Line+1, t.Loc.Col+1)
+}
+
+// DebugTokens 每行打印一个 token，用于调试
+func DebugTokens(tokens []*Token) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString(t.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Start 是 Loc 的别名，和 End 对应
+func (t *Token) Start() Location {
+	return t.Loc
 }
 
 type cursor struct {
@@ -61,330 +372,1084 @@ type cursor struct {
 	loc     Location
 }
 
+// advance 越过当前字节。遇到换行符时行号加一、列号归零，
+// 所有可能包含换行的 lexer 都应该用它来移动光标。
+// 列号按字符计算，UTF-8 的后续字节不占列。
+func (cur *cursor) advance(source string) {
+	switch c := source[cur.pointer]; {
+	case c == '\n':
+		cur.loc.Line++
+		cur.loc.Col = 0
+	case c&0xC0 != 0x80:
+		cur.loc.Col++
+	}
+	cur.pointer++
+}
+
 func (t *Token) equals(other *Token) bool {
 	return t.Value == other.Value && t.Kind == other.Kind
 }
 
-type lexer func(string, cursor) (*Token, cursor, bool)
+// lexer 返回 ok 表示已识别当前位置的内容；
+// 返回 error 表示内容确实属于该 lexer，但格式有误，此时词法分析立即失败。
+type lexer func(string, cursor) (*Token, cursor, bool, error)
+
+// Dialect 选择词法分析遵循的 SQL 方言
+type Dialect uint
+
+const (
+	// DefaultDialect 同时接受各方言的常见写法
+	DefaultDialect Dialect = iota
+	// PostgresDialect 只接受 $1 形式的位置参数
+	PostgresDialect
+	// MySQLDialect 只接受 ? 形式的位置参数，双引号括起来的是字符串，
+	// 标识符可以用反引号括起来
+	MySQLDialect
+)
+
+// LexOptions 控制词法分析的行为，零值和 lex 完全一致
+type LexOptions struct {
+	Dialect Dialect
+	// ExtraKeywords 是在内置关键字之外额外识别的关键字
+	ExtraKeywords []Keyword
+	// KeepTrivia 为 true 时保留空白和注释，见 LexWithTrivia
+	KeepTrivia bool
+	// AllowBacktickIdentifiers 允许 `name` 形式的标识符，
+	// 连续两个反引号表示一个反引号。MySQLDialect 总是允许。
+	AllowBacktickIdentifiers bool
+}
 
 func lex(source string) ([]*Token, error) {
-	tokens := []*Token{}
+	return LexWithOptions(source, LexOptions{})
+}
+
+// LexWithTrivia 和 lex 一样进行词法分析，但保留空白和注释，
+// 分别产生 WhitespaceKind 和 CommentKind 的 token。
+// 把所有 token 的 Raw 依次拼接起来就是原始输入。
+func LexWithTrivia(source string) ([]*Token, error) {
+	return LexWithOptions(source, LexOptions{KeepTrivia: true})
+}
+
+// LexError 是词法分析失败时返回的错误。
+// Loc 是出错 token 的起始位置，Snippet 是从这里开始被跳过的源码，
+// 编辑器可以据此标出出错的范围。
+type LexError struct {
+	Loc     Location
+	Snippet string
+	Msg     string
+}
+
+func (e *LexError) Error() string {
+	return e.Msg
+}
+
+// LexWithOptions 按照 opts 进行词法分析，遇到第一个错误就返回
+func LexWithOptions(source string, opts LexOptions) ([]*Token, error) {
+	tokens, errs := lexWithOptions(source, opts, true)
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return tokens, nil
+}
+
+// LexAll 和 lex 一样进行词法分析，但遇到错误时不会停止：
+// 记录一个 *LexError，跳到下一个空白或分号后继续，
+// 最后返回所有能识别的 token 和所有错误。
+func LexAll(source string) ([]*Token, []error) {
+	return lexWithOptions(source, LexOptions{}, false)
+}
+
+func lexWithOptions(source string, opts LexOptions, failFast bool) ([]*Token, []error) {
+	// 平均每个 token 连同空白大约占六个字节，预先分配避免反复扩容
+	tokens := make([]*Token, 0, len(source)/6+1)
+	var errs []error
 	cur := cursor{}
+	lexers := opts.lexers()
 
 lex:
 	for cur.pointer < uint(len(source)) {
-		lexers := []lexer{lexKeyword, lexSymbol, lexString, lexNumeric, lexIdentifier}
+		// 不保留空白时直接跳过，不必为它创建 token
+		if !opts.KeepTrivia && isWhitespace(source[cur.pointer]) {
+			cur.advance(source)
+			continue
+		}
+
+		var lexErr error
 		for _, l := range lexers {
-			if token, newCursor, ok := l(source, cur); ok {
+			token, newCursor, ok, err := l(source, cur)
+			if err != nil {
+				lexErr = err
+				break
+			}
+			if ok {
 				cur = newCursor
-				if token != nil {
+				if token != nil && (opts.KeepTrivia || !token.isTrivia()) {
 					tokens = append(tokens, token)
 				}
 				continue lex
 			}
 		}
-		hint := ""
-		if len(tokens) > 0 {
-			hint = " after " + tokens[len(tokens)-1].Value
+
+		msg := ""
+		if lexErr != nil {
+			msg = lexErr.Error()
+		} else {
+			hint := ""
+			if len(tokens) > 0 {
+				last := tokens[len(tokens)-1]
+				hint = fmt.Sprintf(" after %s '%s'", last.Kind, last.Value)
+			}
+			msg = fmt.Sprintf("Unable to lex token%s at %d:%d", hint, cur.loc.Line+1, cur.loc.Col+1)
+		}
+
+		next := skipToDelimiter(source, cur)
+		errs = append(errs, &LexError{
+			Loc:     cur.loc,
+			Snippet: source[cur.pointer:next.pointer],
+			Msg:     msg,
+		})
+		if failFast {
+			return nil, errs
 		}
-		return nil, fmt.Errorf("Unable to lex token%s, at %d %d", hint, cur.loc.Line, cur.loc.Col)
+		cur = next
 	}
-	return tokens, nil
+	return tokens, errs
+}
+
+// skipToDelimiter 至少越过一个字符，然后停在下一个空白或分号上
+func skipToDelimiter(source string, ic cursor) cursor {
+	cur := ic
+	cur.advance(source)
+	for cur.pointer < uint(len(source)) {
+		if c := source[cur.pointer]; isWhitespace(c) || c == ';' {
+			return cur
+		}
+		cur.advance(source)
+	}
+	return cur
+}
+
+func isWhitespace(c byte) bool {
+	switch c {
+	case '\n', '\r', '\t', '\f', '\v', ' ':
+		return true
+	}
+	return false
+}
+
+// lexers 按顺序返回这些选项下使用的 lexer
+func (opts LexOptions) lexers() []lexer {
+	keyword := lexKeyword
+	if len(opts.ExtraKeywords) > 0 {
+		options := keywordOptions()
+		for _, k := range opts.ExtraKeywords {
+			options = append(options, strings.ToLower(string(k)))
+		}
+		m := newMatcher(options, true)
+		keyword = func(source string, ic cursor) (*Token, cursor, bool, error) {
+			return lexKeywordWith(source, ic, m)
+		}
+	}
+
+	str := lexString
+	parameter := lexParameter
+	switch opts.Dialect {
+	case PostgresDialect:
+		parameter = func(source string, ic cursor) (*Token, cursor, bool, error) {
+			if ic.pointer < uint(len(source)) && source[ic.pointer] == '?' {
+				return nil, ic, false, nil
+			}
+			return lexParameter(source, ic)
+		}
+	case MySQLDialect:
+		str = func(source string, ic cursor) (*Token, cursor, bool, error) {
+			if token, newCursor, ok, err := lexString(source, ic); ok || err != nil {
+				return token, newCursor, ok, err
+			}
+			return lexCharacterDelimited(source, ic, '"', StringKind)
+		}
+		parameter = func(source string, ic cursor) (*Token, cursor, bool, error) {
+			if ic.pointer < uint(len(source)) && source[ic.pointer] == '$' {
+				return nil, ic, false, nil
+			}
+			return lexParameter(source, ic)
+		}
+	}
+
+	identifier := lexIdentifier
+	if opts.AllowBacktickIdentifiers || opts.Dialect == MySQLDialect {
+		identifier = func(source string, ic cursor) (*Token, cursor, bool, error) {
+			if token, newCursor, ok, err := lexCharacterDelimited(source, ic, '`', IdentifierKind); ok || err != nil {
+				return token, newCursor, ok, err
+			}
+			return lexIdentifier(source, ic)
+		}
+	}
+
+	lexers := []lexer{lexWhitespace, lexComment, keyword, lexSymbol, str}
+	if opts.Dialect != MySQLDialect {
+		lexers = append(lexers, lexDollarString, lexEscapeString)
+	}
+	return append(lexers, lexNumeric, parameter, lexBool, identifier)
+}
+
+// isTrivia 判断 token 是否是空白或注释，默认的词法分析会丢弃它们
+func (t *Token) isTrivia() bool {
+	return t.Kind == WhitespaceKind || t.Kind == CommentKind
+}
+
+// 空白字符连续出现时合并成一个 WhitespaceKind token。
+// \r\n 中只有 \n 换行，\r 和其他空白一样只占一列。
+func lexWhitespace(source string, ic cursor) (*Token, cursor, bool, error) {
+	cur := ic
+
+	for cur.pointer < uint(len(source)) && isWhitespace(source[cur.pointer]) {
+		cur.advance(source)
+	}
+
+	if cur.pointer == ic.pointer {
+		return nil, ic, false, nil
+	}
+
+	return &Token{
+		Value:  source[ic.pointer:cur.pointer],
+		Raw:    source[ic.pointer:cur.pointer],
+		Kind:   WhitespaceKind,
+		Loc:    ic.loc,
+		End:    cur.loc,
+		Pos:    ic.pointer,
+		EndPos: cur.pointer,
+	}, cur, true, nil
 }
 
-func lexNumeric(source string, ic cursor) (*Token, cursor, bool) {
+// 注释产生 CommentKind token，默认和空白一样被丢弃。支持两种注释：
+//   - 单行注释以 -- 开头，一直到行尾（不包括换行符）；
+//   - 块注释 /* ... */ 可以跨越多行，但不支持嵌套，
+//     块注释内部再次出现 /* 会被当作错误。
+func lexComment(source string, ic cursor) (*Token, cursor, bool, error) {
+	if ic.pointer >= uint(len(source)) {
+		return nil, ic, false, nil
+	}
+
+	cur := ic
+
+	if strings.HasPrefix(source[cur.pointer:], "/*") {
+		return lexBlockComment(source, ic)
+	}
+
+	if !strings.HasPrefix(source[cur.pointer:], "--") {
+		return nil, ic, false, nil
+	}
+
+	cur.pointer += 2
+	cur.loc.Col += 2
+
+	for cur.pointer < uint(len(source)) && source[cur.pointer] != '\n' {
+		cur.advance(source)
+	}
+
+	return commentToken(source, ic, cur), cur, true, nil
+}
+
+func commentToken(source string, ic, cur cursor) *Token {
+	return &Token{
+		Value:  source[ic.pointer:cur.pointer],
+		Raw:    source[ic.pointer:cur.pointer],
+		Kind:   CommentKind,
+		Loc:    ic.loc,
+		End:    cur.loc,
+		Pos:    ic.pointer,
+		EndPos: cur.pointer,
+	}
+}
+
+func lexBlockComment(source string, ic cursor) (*Token, cursor, bool, error) {
+	cur := ic
+
+	cur.pointer += 2
+	cur.loc.Col += 2
+
+	for cur.pointer < uint(len(source)) {
+		rest := source[cur.pointer:]
+		switch {
+		case strings.HasPrefix(rest, "*/"):
+			cur.pointer += 2
+			cur.loc.Col += 2
+			return commentToken(source, ic, cur), cur, true, nil
+		case strings.HasPrefix(rest, "/*"):
+			return nil, ic, false, fmt.Errorf("nested block comments are not supported, at line %d col %d", cur.loc.Line+1, cur.loc.Col+1)
+		}
+		cur.advance(source)
+	}
+
+	return nil, ic, false, fmt.Errorf("unterminated block comment starting at line %d col %d", ic.loc.Line+1, ic.loc.Col+1)
+}
+
+// 数字字面量从不包含前导的 + 或 -：-5 是 MinusSymbol 加上数字 5，
+// 1-2 是三个 token。符号由语法分析器通过 FoldSign 折叠进字面量。
+// 和 Postgres 一样，小数点两边可以省略一边：.5、5. 和 5.e3 都是数字。
+func lexNumeric(source string, ic cursor) (*Token, cursor, bool, error) {
+	if ic.pointer >= uint(len(source)) {
+		return nil, ic, false, nil
+	}
+
+	if rest := source[ic.pointer:]; len(rest) > 1 && rest[0] == '0' && (rest[1] == 'x' || rest[1] == 'X') {
+		return lexHexNumeric(source, ic)
+	}
+
 	cur := ic
 
 	periodFound := false
 	expMarkerFound := false
+	separatorFound := false
+	digitFound := false
 
 	for ; cur.pointer < uint(len(source)); cur.pointer++ {
 		c := source[cur.pointer]
-		cur.loc.Col++
 
-		isDigit := c >= '0' && c <= '9'
+		isDigit := isDecimalDigit(c)
 		isPeriod := c == '.'
-		isExpMarker := c == 'e'
+		isExpMarker := c == 'e' || c == 'E'
 
 		// Must start with a digit or period
 		if cur.pointer == ic.pointer {
 			if !isDigit && !isPeriod {
-				return nil, ic, false
+				return nil, ic, false, nil
 			}
 
 			periodFound = isPeriod
+			digitFound = isDigit
 			continue
 		}
 
+		if isDigit {
+			digitFound = true
+			continue
+		}
+
+		// 第二个 . 或 e 结束当前数字，剩下的部分留给下一轮：
+		// 1.2.3 是 1.2 和 .3，1e3e4 是 1e3 和标识符 e4
 		if isPeriod {
 			if periodFound {
-				return nil, ic, false
+				break
 			}
 			periodFound = true
 			continue
 		}
 
-		if isExpMarker {
+		// 单独的 . 后面跟 e 不是数字
+		if isExpMarker && digitFound {
 			if expMarkerFound {
-				return nil, ic, false
+				break
 			}
 			// expMarker 后不允许有.
 			periodFound = true
 			expMarkerFound = true
 
-			// expMarker 后面必须跟数字
-			if cur.pointer == uint(len(source)-1) {
-				return nil, ic, false
+			// expMarker 后面可以有正负号，然后必须跟数字
+			next := cur.pointer + 1
+			if next < uint(len(source)) && (source[next] == '-' || source[next] == '+') {
+				next++
+			}
+			if next >= uint(len(source)) || !isDecimalDigit(source[next]) {
+				return nil, ic, false, fmt.Errorf("exponent marker '%c' must be followed by digits at line %d col %d", c, ic.loc.Line+1, ic.loc.Col+1+(cur.pointer-ic.pointer))
 			}
+			cur.pointer = next
+			continue
+		}
 
-			cNext := source[cur.pointer+1]
-			if cNext == '-' || cNext == '+' {
-				cur.pointer++
-				cur.loc.Col++
+		// 下划线是数字分隔符，只能出现在两个数字之间
+		if c == '_' {
+			prevDigit := isDecimalDigit(source[cur.pointer-1])
+			nextDigit := cur.pointer+1 < uint(len(source)) && isDecimalDigit(source[cur.pointer+1])
+			if !prevDigit || !nextDigit {
+				return nil, ic, false, fmt.Errorf("digit separator '_' must be between digits at line %d col %d", ic.loc.Line+1, ic.loc.Col+1+(cur.pointer-ic.pointer))
 			}
+			separatorFound = true
 			continue
 		}
 
-		if !isDigit {
+		break
+	}
+
+	// 至少要有一个数字，单独的 . 留给符号
+	if !digitFound {
+		return nil, ic, false, nil
+	}
+
+	// 数字不会跨行，列号只算实际读入的字符
+	cur.loc.Col = ic.loc.Col + (cur.pointer - ic.pointer)
+
+	value := source[ic.pointer:cur.pointer]
+	if separatorFound {
+		value = strings.ReplaceAll(value, "_", "")
+	}
+
+	return &Token{
+		Value:  value,
+		Raw:    source[ic.pointer:cur.pointer],
+		Loc:    ic.loc,
+		End:    cur.loc,
+		Pos:    ic.pointer,
+		EndPos: cur.pointer,
+		Kind:   NumericKind,
+	}, cur, true, nil
+}
+
+// FoldSign 把一元的 + 或 - 折叠进紧随其后的数字字面量，
+// 返回带符号的新 token，位置从符号开始。
+// sign 不是 + 或 -、num 不是数字，或者两者之间有空白时返回 false，
+// 这样新 token 的 Raw 仍然是源码中 [Pos, EndPos) 的文本。
+func FoldSign(sign, num *Token) (*Token, bool) {
+	if sign.Kind != OperatorKind || num.Kind != NumericKind || sign.EndPos != num.Pos {
+		return nil, false
+	}
+
+	value := num.Value
+	switch Symbol(sign.Value) {
+	case PlusSymbol:
+	case MinusSymbol:
+		if strings.HasPrefix(value, "-") {
+			value = value[1:]
+		} else {
+			value = "-" + value
+		}
+	default:
+		return nil, false
+	}
+
+	return &Token{
+		Value:  value,
+		Raw:    sign.Raw + num.Raw,
+		Kind:   NumericKind,
+		Loc:    sign.Loc,
+		End:    num.End,
+		Pos:    sign.Pos,
+		EndPos: num.EndPos,
+	}, true
+}
+
+// 十六进制整数以 0x 或 0X 开头，后面至少跟一个十六进制数字。
+// token 的值保留原始文本。
+func lexHexNumeric(source string, ic cursor) (*Token, cursor, bool, error) {
+	cur := ic
+
+	cur.pointer += 2
+	cur.loc.Col += 2
+
+	for ; cur.pointer < uint(len(source)); cur.pointer++ {
+		if !isHexDigit(source[cur.pointer]) {
 			break
 		}
+		cur.loc.Col++
 	}
 
-	// 没有累积字符
-	if cur.pointer == ic.pointer {
-		return nil, ic, false
+	if cur.pointer == ic.pointer+2 {
+		return nil, ic, false, fmt.Errorf("expected hexadecimal digits after 'x' at line %d col %d", ic.loc.Line+1, ic.loc.Col+2)
 	}
 
 	return &Token{
-		Value: source[ic.pointer:cur.pointer],
-		Loc:   ic.loc,
-		Kind:  NumericKind,
-	}, cur, true
-
+		Value:  source[ic.pointer:cur.pointer],
+		Raw:    source[ic.pointer:cur.pointer],
+		Loc:    ic.loc,
+		End:    cur.loc,
+		Pos:    ic.pointer,
+		EndPos: cur.pointer,
+		Kind:   NumericKind,
+	}, cur, true, nil
 }
 
 // 字符串必须以单个撇号开头和结尾。
 // 如果后面跟着另一个撇号，它们可以包含一个撇号。
 // 我们将把这种字符分隔的词法逻辑放入辅助函数中，
-// 以便在分析标识符时再次使用它。
-func lexCharacterDelimited(source string, ic cursor, delimiter byte) (*Token, cursor, bool) {
+// 以便在分析标识符时再次使用它，kind 决定产生的 token 类型。
+func lexCharacterDelimited(source string, ic cursor, delimiter byte, kind TokenKind) (*Token, cursor, bool, error) {
 	cur := ic
 
-	if len(source[cur.pointer:]) == 0 {
-		return nil, ic, false
-	}
-
-	if source[cur.pointer] != delimiter {
-		return nil, ic, false
+	if cur.pointer >= uint(len(source)) || source[cur.pointer] != delimiter {
+		return nil, ic, false, nil
 	}
 
 	cur.loc.Col++
 	cur.pointer++
 
-	var value []byte
+	// 没有转义时 Value 直接引用源码，不需要复制
+	escaped := false
 
-	for ; cur.pointer < uint(len(source)); cur.pointer++ {
+	for cur.pointer < uint(len(source)) {
 		c := source[cur.pointer]
 
 		if c == delimiter {
 			// SQL 转义是通过双字符，而不是反斜線。
 			if cur.pointer+1 >= uint(len(source)) || source[cur.pointer+1] != delimiter {
+				value := source[ic.pointer+1 : cur.pointer]
+				if escaped {
+					d := string(delimiter)
+					value = strings.ReplaceAll(value, d+d, d)
+				}
+				// 越过结尾的分隔符
+				cur.advance(source)
 				return &Token{
-					Value: string(value),
-					Loc:   ic.loc,
-					Kind:  StringKind,
-				}, cur, true
-			} else {
-				value = append(value, delimiter)
-				cur.pointer++
-				cur.loc.Col++
+					Value:  value,
+					Raw:    source[ic.pointer:cur.pointer],
+					Loc:    ic.loc,
+					End:    cur.loc,
+					Pos:    ic.pointer,
+					EndPos: cur.pointer,
+					Kind:   kind,
+				}, cur, true, nil
 			}
+
+			// 两个分隔符只代表一个字符
+			escaped = true
+			cur.advance(source)
+			cur.advance(source)
+			continue
 		}
-		value = append(value, c)
-		cur.loc.Col++
+		cur.advance(source)
 	}
 
-	return nil, ic, false
+	// 已经读到了开头的分隔符，却没有找到结尾
+	what := "string literal"
+	if kind == IdentifierKind {
+		what = "quoted identifier"
+	}
+	return nil, ic, false, fmt.Errorf("unterminated %s starting at line %d col %d", what, ic.loc.Line+1, ic.loc.Col+1)
 }
 
-func lexString(source string, ic cursor) (*Token, cursor, bool) {
-	return lexCharacterDelimited(source, ic, '\'')
+func lexString(source string, ic cursor) (*Token, cursor, bool, error) {
+	return lexCharacterDelimited(source, ic, '\'', StringKind)
 }
 
-// 分析符号和关键字
-// 符号来自一组固定的字符串，
-// 因此很容易进行比较。空白应该被扔掉。
-func lexSymbol(source string, ic cursor) (*Token, cursor, bool) {
-	c := source[ic.pointer]
+// Postgres 的转义字符串 E'...'，E 和引号之间不能有空白。
+// 支持 \n、\t、\\、\' 和 \xNN 转义，也支持用两个撇号表示一个撇号。
+func lexEscapeString(source string, ic cursor) (*Token, cursor, bool, error) {
+	if ic.pointer >= uint(len(source)) {
+		return nil, ic, false, nil
+	}
+
 	cur := ic
 
-	// 如果不是被忽略的语法，以后会被覆盖
-	cur.pointer++
-	cur.loc.Col++
+	rest := source[cur.pointer:]
+	if len(rest) < 2 || (rest[0] != 'e' && rest[0] != 'E') || rest[1] != '\'' {
+		return nil, ic, false, nil
+	}
 
-	switch c {
-	case '\n':
-		cur.loc.Line++
-		cur.loc.Col = 0
-		fallthrough
-	case '\t':
-		fallthrough
-	case ' ':
-		return nil, cur, true
+	cur.advance(source)
+	cur.advance(source)
+
+	var value []byte
+	for cur.pointer < uint(len(source)) {
+		c := source[cur.pointer]
+
+		if c == '\'' {
+			cur.advance(source)
+			if cur.pointer < uint(len(source)) && source[cur.pointer] == '\'' {
+				value = append(value, '\'')
+				cur.advance(source)
+				continue
+			}
+
+			return &Token{
+				Value:  string(value),
+				Raw:    source[ic.pointer:cur.pointer],
+				Kind:   StringKind,
+				Loc:    ic.loc,
+				End:    cur.loc,
+				Pos:    ic.pointer,
+				EndPos: cur.pointer,
+			}, cur, true, nil
+		}
+
+		if c != '\\' {
+			value = append(value, c)
+			cur.advance(source)
+			continue
+		}
+
+		escape := cur
+		cur.advance(source)
+		if cur.pointer >= uint(len(source)) {
+			break
+		}
+
+		switch source[cur.pointer] {
+		case 'n':
+			value = append(value, '\n')
+		case 't':
+			value = append(value, '\t')
+		case '\\':
+			value = append(value, '\\')
+		case '\'':
+			value = append(value, '\'')
+		case 'x':
+			hex := source[cur.pointer+1:]
+			if len(hex) < 2 || !isHexDigit(hex[0]) || !isHexDigit(hex[1]) {
+				return nil, ic, false, fmt.Errorf("invalid \\x escape, expected two hexadecimal digits at line %d col %d", escape.loc.Line+1, escape.loc.Col+1)
+			}
+			value = append(value, hexValue(hex[0])<<4|hexValue(hex[1]))
+			cur.advance(source)
+			cur.advance(source)
+		default:
+			r, _ := utf8.DecodeRuneInString(source[cur.pointer:])
+			return nil, ic, false, fmt.Errorf("invalid escape sequence \\%c at line %d col %d", r, escape.loc.Line+1, escape.loc.Col+1)
+		}
+		cur.advance(source)
 	}
 
-	// 应该保留的语法
-	symbols := []Symbol{
-		CommaSymbol,
-		LeftParenSymbol,
-		RightParenSymbol,
-		SemicolonSymbol,
-		AsteriskSymbol,
+	return nil, ic, false, fmt.Errorf("unterminated string literal starting at line %d col %d", ic.loc.Line+1, ic.loc.Col+1)
+}
+
+func isDecimalDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func hexValue(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
 	}
+	return c - '0'
+}
 
-	var options []string
-	for _, s := range symbols {
-		options = append(options, string(s))
+// Postgres 的美元符号引用字符串：$$...$$ 或 $tag$...$tag$。
+// tag 可以为空，否则和标识符一样以字母或下划线开头，不能以数字开头，
+// 所以 $1 仍然是位置参数。内容原样保留，不处理任何转义。
+func lexDollarString(source string, ic cursor) (*Token, cursor, bool, error) {
+	if ic.pointer >= uint(len(source)) {
+		return nil, ic, false, nil
 	}
 
-	match := longestMatch(source, ic, options)
+	cur := ic
+
+	if source[cur.pointer] != '$' {
+		return nil, ic, false, nil
+	}
+
+	end := cur.pointer + 1
+	for ; end < uint(len(source)) && source[end] != '$'; end++ {
+		c := source[end]
+		isTagChar := isAlphabetical(c) || c == '_' || (end > cur.pointer+1 && c >= '0' && c <= '9')
+		if !isTagChar {
+			return nil, ic, false, nil
+		}
+	}
+	if end >= uint(len(source)) {
+		return nil, ic, false, nil
+	}
+
+	delimiter := source[cur.pointer : end+1]
+	for cur.pointer <= end {
+		cur.advance(source)
+	}
+
+	length := strings.Index(source[cur.pointer:], delimiter)
+	if length < 0 {
+		return nil, ic, false, fmt.Errorf("unterminated dollar-quoted string starting at line %d col %d, expected closing %s", ic.loc.Line+1, ic.loc.Col+1, delimiter)
+	}
+
+	start := cur.pointer
+	for cur.pointer < start+uint(length)+uint(len(delimiter)) {
+		cur.advance(source)
+	}
+
+	return &Token{
+		Value:  source[start : start+uint(length)],
+		Raw:    source[ic.pointer:cur.pointer],
+		Kind:   StringKind,
+		Loc:    ic.loc,
+		End:    cur.loc,
+		Pos:    ic.pointer,
+		EndPos: cur.pointer,
+	}, cur, true, nil
+}
+
+// punctuation 是只起分隔作用的符号，其余符号都是运算符
+var punctuation = map[Symbol]bool{
+	SemicolonSymbol:  true,
+	CommaSymbol:      true,
+	LeftParenSymbol:  true,
+	RightParenSymbol: true,
+	DotSymbol:        true,
+}
+
+// 分析符号和关键字
+// 符号来自一组固定的字符串，
+// 因此很容易进行比较。
+//
+// * 总是作为乘法运算符返回，
+// 语法分析器在投影列（select * 或 count(*)）的位置上把它当作通配符。
+func lexSymbol(source string, ic cursor) (*Token, cursor, bool, error) {
+	if ic.pointer >= uint(len(source)) {
+		return nil, ic, false, nil
+	}
+
+	c := source[ic.pointer]
+	cur := ic
+
+	match := symbolMatcher.longestMatch(source, ic)
 
 	if match == "" {
-		return nil, ic, false
+		// 单个 | 不是操作符，多半是 || 少写了一个
+		if c == '|' {
+			return nil, ic, false, fmt.Errorf("unexpected '|' at line %d col %d, did you mean '||'", ic.loc.Line+1, ic.loc.Col+1)
+		}
+		return nil, ic, false, nil
+	}
+
+	// . 后面紧跟数字是 .5 这样的数字，留给 lexNumeric
+	if match == string(DotSymbol) && ic.pointer+1 < uint(len(source)) && isDecimalDigit(source[ic.pointer+1]) {
+		return nil, ic, false, nil
 	}
 
 	cur.pointer = ic.pointer + uint(len(match))
 	cur.loc.Col = ic.loc.Col + uint(len(match))
 
+	// != 和 <> 是同一个操作符，统一成 <>
+	if match == string(AltNeqSymbol) {
+		match = string(NeqSymbol)
+	}
+
+	kind := OperatorKind
+	if punctuation[Symbol(match)] {
+		kind = SymbolKind
+	}
+
 	return &Token{
-		Value: match,
-		Loc:   ic.loc,
-		Kind:  SymbolKind,
-	}, cur, true
+		Value:  match,
+		Raw:    source[ic.pointer:cur.pointer],
+		Loc:    ic.loc,
+		End:    cur.loc,
+		Pos:    ic.pointer,
+		EndPos: cur.pointer,
+		Kind:   kind,
+	}, cur, true, nil
 }
 
-func lexKeyword(source string, ic cursor) (*Token, cursor, bool) {
-	cur := ic
-	keywords := []Keyword{
-		SelectKeyword,
-		InsertKeyword,
-		ValuesKeyword,
-		TableKeyword,
-		CreateKeyword,
-		WhereKeyword,
-		FromKeyword,
-		IntoKeyword,
-		TextKeyword,
-	}
-
-	var options []string
-	for _, k := range keywords {
-		options = append(options, string(k))
-	}
+func lexKeyword(source string, ic cursor) (*Token, cursor, bool, error) {
+	return lexKeywordWith(source, ic, keywordMatcher)
+}
 
-	match := longestMatch(source, ic, options)
+func lexKeywordWith(source string, ic cursor, m *matcher) (*Token, cursor, bool, error) {
+	if ic.pointer >= uint(len(source)) {
+		return nil, ic, false, nil
+	}
 
+	cur := ic
+	// 关键字只在单词边界处匹配，andy 和 nothing 都是标识符
+	match := m.longestMatch(source, ic)
 	if match == "" {
-		return nil, ic, false
+		return nil, ic, false, nil
 	}
 
 	cur.pointer = ic.pointer + uint(len(match))
 	cur.loc.Col = ic.loc.Col + uint(len(match))
 
 	return &Token{
-		Value: match,
-		Kind:  KeywordKind,
-		Loc:   ic.loc,
-	}, cur, true
+		Value:  match,
+		Raw:    source[ic.pointer:cur.pointer],
+		Kind:   KeywordKind,
+		Loc:    ic.loc,
+		End:    cur.loc,
+		Pos:    ic.pointer,
+		EndPos: cur.pointer,
+	}, cur, true, nil
 }
 
-// longestMatch 从给定的光标开始遍历源字符串
-// 在提供的 func 中找到最长的匹配子字符串
-func longestMatch(source string, ic cursor, options []string) string {
-	var value []byte
-	var skipList []int
-	var match string
+// 位置参数有两种写法：Postgres 风格的 $1、$2（从 1 开始编号）
+// 和 MySQL/ODBC 风格的 ?。token 的值保留原始文本。
+// $ 后面跟字母时不是参数，交给其他 lexer 处理。
+//
+// 命名参数写作 :name，产生 NamedParameterKind，值是不带冒号的名字，
+// 保留原始大小写。:: 留给类型转换操作符。
+func lexParameter(source string, ic cursor) (*Token, cursor, bool, error) {
+	if ic.pointer >= uint(len(source)) {
+		return nil, ic, false, nil
+	}
 
 	cur := ic
-	for cur.pointer < uint(len(source)) {
-		value = append(value, strings.ToLower(string(source[cur.pointer]))...)
+
+	if source[cur.pointer] == ':' {
+		return lexNamedParameter(source, ic)
+	}
+
+	switch source[cur.pointer] {
+	case '?':
+		cur.pointer++
+		cur.loc.Col++
+	case '$':
 		cur.pointer++
+		cur.loc.Col++
 
-	match:
-		for i, option := range options {
-			for _, skip := range skipList {
-				if i == skip {
-					continue match
-				}
+		nonZero := false
+		for ; cur.pointer < uint(len(source)); cur.pointer++ {
+			c := source[cur.pointer]
+			if c < '0' || c > '9' {
+				break
 			}
+			nonZero = nonZero || c != '0'
+			cur.loc.Col++
+		}
 
-			// 处理 INT vs INTO 的情况
-			if option == string(value) {
-				skipList = append(skipList, i)
+		if cur.pointer == ic.pointer+1 {
+			return nil, ic, false, nil
+		}
+		if !nonZero {
+			return nil, ic, false, fmt.Errorf("parameter numbers start at $1, found %s at line %d col %d", source[ic.pointer:cur.pointer], ic.loc.Line+1, ic.loc.Col+1)
+		}
+	default:
+		return nil, ic, false, nil
+	}
 
-				if len(option) > len(match) {
-					match = option
-				}
-				continue
-			}
+	return &Token{
+		Value:  source[ic.pointer:cur.pointer],
+		Raw:    source[ic.pointer:cur.pointer],
+		Kind:   ParameterKind,
+		Loc:    ic.loc,
+		End:    cur.loc,
+		Pos:    ic.pointer,
+		EndPos: cur.pointer,
+	}, cur, true, nil
+}
+
+func lexNamedParameter(source string, ic cursor) (*Token, cursor, bool, error) {
+	if ic.pointer >= uint(len(source)) {
+		return nil, ic, false, nil
+	}
+
+	cur := ic
+
+	if strings.HasPrefix(source[cur.pointer:], "::") {
+		return nil, ic, false, nil
+	}
+
+	cur.pointer++
+	cur.loc.Col++
+
+	if cur.pointer >= uint(len(source)) || !isAlphabetical(source[cur.pointer]) {
+		return nil, ic, false, fmt.Errorf("expected parameter name after ':' at line %d col %d", ic.loc.Line+1, ic.loc.Col+1)
+	}
+
+	for n := wordCharLen(source, cur.pointer); n > 0; n = wordCharLen(source, cur.pointer) {
+		cur.pointer += n
+		cur.loc.Col++
+	}
+
+	return &Token{
+		Value:  source[ic.pointer+1 : cur.pointer],
+		Raw:    source[ic.pointer:cur.pointer],
+		Kind:   NamedParameterKind,
+		Loc:    ic.loc,
+		End:    cur.loc,
+		Pos:    ic.pointer,
+		EndPos: cur.pointer,
+	}, cur, true, nil
+}
+
+func isAlphabetical(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
 
-			sharesPrefix := string(value) == option[:cur.pointer-ic.pointer]
-			tooLong := len(value) > len(option)
-			if tooLong || !sharesPrefix {
-				skipList = append(skipList, i)
+// 布尔字面量 true 和 false，不区分大小写。
+// 后面紧跟字母、数字、下划线或 $ 时（比如 truevalue）只是标识符的一部分。
+func lexBool(source string, ic cursor) (*Token, cursor, bool, error) {
+	if ic.pointer >= uint(len(source)) {
+		return nil, ic, false, nil
+	}
+
+	cur := ic
+
+	for _, value := range []string{"true", "false"} {
+		end := ic.pointer + uint(len(value))
+		if end > uint(len(source)) || strings.ToLower(source[ic.pointer:end]) != value {
+			continue
+		}
+		if continuesWord(source, end) {
+			return nil, ic, false, nil
+		}
+
+		cur.pointer = end
+		cur.loc.Col = ic.loc.Col + uint(len(value))
+
+		return &Token{
+			Value:  value,
+			Raw:    source[ic.pointer:cur.pointer],
+			Kind:   BoolKind,
+			Loc:    ic.loc,
+			End:    cur.loc,
+			Pos:    ic.pointer,
+			EndPos: cur.pointer,
+		}, cur, true, nil
+	}
+
+	return nil, ic, false, nil
+}
+
+// continuesWord 判断 source[i] 是否会和前面的字符连成同一个单词，
+// 和 lexIdentifier 一致：字母（包括 Unicode 字母）、数字、下划线和 $。
+func continuesWord(source string, i uint) bool {
+	return wordCharLen(source, i) > 0
+}
+
+// wordCharLen 返回从 source[i] 开始的单词字符占用的字节数，不是单词字符时返回 0
+func wordCharLen(source string, i uint) uint {
+	if i >= uint(len(source)) {
+		return 0
+	}
+	c := source[i]
+	if c < utf8.RuneSelf {
+		if isAlphabetical(c) || isDecimalDigit(c) || c == '_' || c == '$' {
+			return 1
+		}
+		return 0
+	}
+	r, size := utf8.DecodeRuneInString(source[i:])
+	if unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return uint(size)
+	}
+	return 0
+}
+
+// matcher 是一棵按字节组织的前缀树，关键字和符号各有一棵，
+// 在包初始化时构建好，lexer 每次调用时直接使用。
+type matcher struct {
+	root *trieNode
+	// words 为 true 时匹配必须在单词边界结束，用于关键字
+	words bool
+}
+
+// 每个节点的分支很少，线性查找切片比 map 快
+type trieNode struct {
+	labels   []byte
+	children []*trieNode
+	// 非空表示从根到这里是一个完整的选项
+	option string
+}
+
+func (n *trieNode) child(c byte) *trieNode {
+	for i, label := range n.labels {
+		if label == c {
+			return n.children[i]
+		}
+	}
+	return nil
+}
+
+func newMatcher(options []string, words bool) *matcher {
+	m := &matcher{root: &trieNode{}, words: words}
+	for _, option := range options {
+		node := m.root
+		for i := 0; i < len(option); i++ {
+			next := node.child(option[i])
+			if next == nil {
+				next = &trieNode{}
+				node.labels = append(node.labels, option[i])
+				node.children = append(node.children, next)
 			}
+			node = next
+		}
+		node.option = option
+	}
+	return m
+}
+
+// longestMatch 从给定的光标开始沿前缀树向下走，
+// 不区分大小写地找到最长的完整选项（处理 INT vs INTO 的情况）。
+func (m *matcher) longestMatch(source string, ic cursor) string {
+	var match string
+
+	node := m.root
+	for i := ic.pointer; i < uint(len(source)); i++ {
+		c := source[i]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
 		}
 
-		if len(skipList) == len(options) {
+		node = node.child(c)
+		if node == nil {
 			break
 		}
+
+		if node.option != "" && !(m.words && continuesWord(source, i+1)) {
+			match = node.option
+		}
 	}
+
 	return match
 }
 
 // 标识符可以是双引号字符串，
-// 也可以是一组以字母字符开头的字符，可能包含数字和下划线。
-func lexIdentifier(source string, ic cursor) (*Token, cursor, bool) {
+// 也可以是一组以字母开头的字符，可能包含数字、下划线和 $。
+// 字母包括所有 Unicode 字母，列号按字符而不是字节计算。
+func lexIdentifier(source string, ic cursor) (*Token, cursor, bool, error) {
+	if ic.pointer >= uint(len(source)) {
+		return nil, ic, false, nil
+	}
 
 	// 如果是双引号标识符，则单独处理
-	if token, newCursor, ok := lexCharacterDelimited(source, ic, '"'); ok {
-		return token, newCursor, true
+	if token, newCursor, ok, err := lexCharacterDelimited(source, ic, '"', IdentifierKind); ok || err != nil {
+		return token, newCursor, ok, err
 	}
 
 	cur := ic
-	c := source[cur.pointer]
-	// 其他字符也计算在内，暂时忽略非 ascii
-	isAlphabetical := (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
-	if !isAlphabetical {
-		return nil, ic, false
+
+	r, size := utf8.DecodeRuneInString(source[cur.pointer:])
+	if !unicode.IsLetter(r) {
+		return nil, ic, false, nil
 	}
 
-	cur.pointer++
+	cur.pointer += uint(size)
 	cur.loc.Col++
 
-	value := []byte{c}
+	for cur.pointer < uint(len(source)) {
+		r, size = utf8.DecodeRuneInString(source[cur.pointer:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '$' && r != '_' {
+			break
+		}
+		cur.pointer += uint(size)
+		cur.loc.Col++
+	}
 
-	for ; cur.pointer < uint(len(source)); cur.pointer++ {
-		c = source[cur.pointer]
+	return &Token{
+		// 不带引号的标识符不区分大小写，只折叠 ASCII 字母
+		Value:  asciiLower(source[ic.pointer:cur.pointer]),
+		Raw:    source[ic.pointer:cur.pointer],
+		Loc:    ic.loc,
+		End:    cur.loc,
+		Pos:    ic.pointer,
+		EndPos: cur.pointer,
+		Kind:   IdentifierKind,
+	}, cur, true, nil
+}
 
-		// 其他字符也计算在内，暂时忽略非 ascii
-		isAlphabetical := (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
-		isNumeric := c >= '0' && c <= '9'
-		if isAlphabetical || isNumeric || c == '$' || c == '_' {
-			value = append(value, c)
-			cur.loc.Col++
-			continue
+// asciiLower 只把 ASCII 大写字母转成小写，其他字节保持不变
+// 已经是小写时直接返回 s，不分配内存。
+func asciiLower(s string) string {
+	i := 0
+	for ; i < len(s); i++ {
+		if c := s[i]; c >= 'A' && c <= 'Z' {
+			break
 		}
-		break
 	}
-	if len(value) == 0 {
-		return nil, ic, false
+	if i == len(s) {
+		return s
 	}
 
-	return &Token{ // 不带引号的标识符不区分大小写
-		Value: strings.ToLower(string(value)),
-		Loc:   ic.loc,
-		Kind:  IdentifierKind,
-	}, cur, true
-
+	var b strings.Builder
+	b.Grow(len(s))
+	b.WriteString(s[:i])
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
 }
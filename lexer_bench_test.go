@@ -0,0 +1,62 @@
+package gosql
+
+import (
+	"strings"
+	"testing"
+)
+
+// 一万条语句的脚本，和真实的 INSERT 导入脚本差不多
+func benchmarkSource() string {
+	var b strings.Builder
+	for i := 0; i < 10000; i++ {
+		switch i % 4 {
+		case 0:
+			b.WriteString("INSERT INTO users VALUES (105, 'Ada Lovelace', 2.5e3);\n")
+		case 1:
+			b.WriteString("select id, name from users where id >= 10 and name <> 'x';\n")
+		case 2:
+			b.WriteString("CREATE TABLE orders (id INT, note TEXT); -- schema\n")
+		default:
+			b.WriteString("select price * qty + tax, 'a' || 'b' from items where not null;\n")
+		}
+	}
+	return b.String()
+}
+
+func BenchmarkLex(b *testing.B) {
+	source := benchmarkSource()
+	b.SetBytes(int64(len(source)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := lex(source); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLongestMatch(b *testing.B) {
+	source := benchmarkSource()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for p := uint(0); p < 4096; p++ {
+			keywordMatcher.longestMatch(source, cursor{pointer: p})
+		}
+	}
+}
+
+func BenchmarkLongestMatchReference(b *testing.B) {
+	source := benchmarkSource()
+	options := keywordOptions()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for p := uint(0); p < 4096; p++ {
+			longestMatchReference(source, cursor{pointer: p}, options)
+		}
+	}
+}
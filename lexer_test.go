@@ -1,6 +1,8 @@
 package gosql
 
 import (
+	"errors"
+	"math/rand"
 	"strings"
 	"testing"
 
@@ -11,6 +13,9 @@ func TestToken_lexNumeric(t *testing.T) {
 	tests := []struct {
 		number bool
 		value  string
+		want   string
+		raw    string
+		err    string
 	}{
 		{
 			number: true,
@@ -60,6 +65,83 @@ func TestToken_lexNumeric(t *testing.T) {
 			number: true,
 			value:  "4.",
 		},
+		{
+			number: true,
+			value:  "0x1F",
+		},
+		{
+			number: true,
+			value:  "0Xff ",
+		},
+		{
+			number: true,
+			value:  "0",
+		},
+		{
+			number: true,
+			value:  "1E+10",
+		},
+		{
+			number: true,
+			value:  "2E5",
+		},
+		{
+			number: true,
+			value:  "5.",
+		},
+		{
+			number: true,
+			value:  ".5e-3",
+		},
+		{
+			number: true,
+			value:  "1_000",
+			want:   "1000",
+		},
+		{
+			number: true,
+			value:  "10_000_000 ",
+			want:   "10000000",
+		},
+		{
+			number: true,
+			value:  "1_000e1_0",
+			want:   "1000e10",
+		},
+		{
+			number: true,
+			value:  "1.000_5",
+			want:   "1.0005",
+		},
+		{
+			number: true,
+			value:  "5.e3",
+		},
+		// 第二个 . 或 e 结束数字，剩下的留给下一个 token
+		{
+			number: true,
+			value:  "1.2.3",
+			want:   "1.2",
+			raw:    "1.2",
+		},
+		{
+			number: true,
+			value:  "1..2",
+			want:   "1.",
+			raw:    "1.",
+		},
+		{
+			number: true,
+			value:  "1e3e4",
+			want:   "1e3",
+			raw:    "1e3",
+		},
+		{
+			number: true,
+			value:  "1e3.5",
+			want:   "1e3",
+			raw:    "1e3",
+		},
 		// false tests
 		{
 			number: false,
@@ -67,23 +149,94 @@ func TestToken_lexNumeric(t *testing.T) {
 		},
 		{
 			number: false,
-			value:  "1..",
+			value:  "1ee4",
+			err:    "exponent marker 'e' must be followed by digits at line 1 col 2",
 		},
 		{
 			number: false,
-			value:  "1ee4",
+			value:  " 1",
 		},
 		{
 			number: false,
-			value:  " 1",
+			value:  ".",
+		},
+		{
+			number: false,
+			value:  ". 5",
+		},
+		{
+			number: false,
+			value:  ".e5",
+		},
+		{
+			number: false,
+			value:  "1e",
+			err:    "exponent marker 'e' must be followed by digits at line 1 col 2",
+		},
+		{
+			number: false,
+			value:  "1e+",
+			err:    "exponent marker 'e' must be followed by digits at line 1 col 2",
+		},
+		{
+			number: false,
+			value:  "12.5E-x",
+			err:    "exponent marker 'E' must be followed by digits at line 1 col 5",
+		},
+		{
+			number: false,
+			value:  "1ea",
+			err:    "exponent marker 'e' must be followed by digits at line 1 col 2",
+		},
+		{
+			number: false,
+			value:  "1__0",
+			err:    "digit separator '_' must be between digits at line 1 col 2",
+		},
+		{
+			number: false,
+			value:  "1_.5",
+			err:    "digit separator '_' must be between digits at line 1 col 2",
+		},
+		{
+			number: false,
+			value:  "1._5",
+			err:    "digit separator '_' must be between digits at line 1 col 3",
+		},
+		{
+			number: false,
+			value:  "1_e5",
+			err:    "digit separator '_' must be between digits at line 1 col 2",
+		},
+		{
+			number: false,
+			value:  "1e_5",
+			err:    "exponent marker 'e' must be followed by digits at line 1 col 2",
+		},
+		{
+			number: false,
+			value:  "1000_",
+			err:    "digit separator '_' must be between digits at line 1 col 5",
 		},
 	}
 
 	for _, test := range tests {
-		tok, _, ok := lexNumeric(test.value, cursor{})
+		tok, _, ok, err := lexNumeric(test.value, cursor{})
 		assert.Equal(t, test.number, ok, test.value)
 		if ok {
-			assert.Equal(t, strings.TrimSpace(test.value), tok.Value, test.value)
+			if test.want == "" {
+				test.want = strings.TrimSpace(test.value)
+			}
+			if test.raw == "" {
+				test.raw = strings.TrimSpace(test.value)
+			}
+			assert.Equal(t, test.want, tok.Value, test.value)
+			assert.Equal(t, test.raw, tok.Raw, test.value)
+		}
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.value)
+		} else {
+			assert.Nil(t, err, test.value)
 		}
 	}
 }
@@ -92,6 +245,8 @@ func TestToken_lexString(t *testing.T) {
 	tests := []struct {
 		string bool
 		value  string
+		want   string
+		err    string
 	}{
 		{
 			string: false,
@@ -112,11 +267,43 @@ func TestToken_lexString(t *testing.T) {
 		{
 			string: true,
 			value:  "'a '' b'",
+			want:   "a ' b",
+		},
+		{
+			string: true,
+			value:  "'it''s'",
+			want:   "it's",
+		},
+		{
+			string: true,
+			value:  "''''",
+			want:   "'",
+		},
+		{
+			string: true,
+			value:  "'abc'''",
+			want:   "abc'",
+		},
+		{
+			string: true,
+			value:  "''",
+			want:   "",
 		},
 		// false tests
 		{
 			string: false,
 			value:  "'",
+			err:    "unterminated string literal starting at line 1 col 1",
+		},
+		{
+			string: false,
+			value:  "'abc",
+			err:    "unterminated string literal starting at line 1 col 1",
+		},
+		{
+			string: false,
+			value:  "'abc''",
+			err:    "unterminated string literal starting at line 1 col 1",
 		},
 		{
 			string: false,
@@ -129,384 +316,2694 @@ func TestToken_lexString(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		tok, _, ok := lexString(test.value, cursor{})
+		tok, _, ok, err := lexString(test.value, cursor{})
 		assert.Equal(t, test.string, ok, test.value)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.value)
+		} else {
+			assert.Nil(t, err, test.value)
+		}
 		if ok {
-			test.value = strings.TrimSpace(test.value)
-			assert.Equal(t, test.value[1:len(test.value)-1], tok.Value, test.value)
+			assert.Equal(t, StringKind, tok.Kind, test.value)
+			if test.want == "" {
+				trimmed := strings.TrimSpace(test.value)
+				test.want = trimmed[1 : len(trimmed)-1]
+			}
+			assert.Equal(t, test.want, tok.Value, test.value)
 		}
 	}
 }
 
-func TestToken_lexSymbol(t *testing.T) {
+func TestToken_lexEscapeString(t *testing.T) {
 	tests := []struct {
-		symbol bool
+		string bool
+		input  string
 		value  string
+		err    string
 	}{
 		{
-			symbol: true,
-			value:  "= ",
+			string: true,
+			input:  `E'line1\nline2'`,
+			value:  "line1\nline2",
 		},
 		{
-			symbol: true,
-			value:  "||",
+			string: true,
+			input:  `e'a\tb\\c\'d'`,
+			value:  "a\tb\\c'd",
+		},
+		{
+			string: true,
+			input:  `E'\x41\x7a'`,
+			value:  "Az",
+		},
+		{
+			string: true,
+			input:  `E'it''s' `,
+			value:  "it's",
+		},
+		// false tests
+		{
+			string: false,
+			input:  "E 'a'",
+		},
+		{
+			string: false,
+			input:  "email",
+		},
+		{
+			string: false,
+			input:  `E'a\qb'`,
+			err:    `invalid escape sequence \q at line 1 col 4`,
+		},
+		{
+			string: false,
+			input:  `E'ab\x4'`,
+			err:    `invalid \x escape, expected two hexadecimal digits at line 1 col 5`,
+		},
+		{
+			string: false,
+			input:  `E'abc\'`,
+			err:    "unterminated string literal starting at line 1 col 1",
+		},
+		{
+			string: false,
+			input:  `E'abc\`,
+			err:    "unterminated string literal starting at line 1 col 1",
 		},
 	}
 
 	for _, test := range tests {
-		tok, _, ok := lexSymbol(test.value, cursor{})
-		assert.Equal(t, test.symbol, ok, test.value)
+		tok, _, ok, err := lexEscapeString(test.input, cursor{})
+		assert.Equal(t, test.string, ok, test.input)
 		if ok {
-			test.value = strings.TrimSpace(test.value)
-			assert.Equal(t, test.value, tok.Value, test.value)
+			assert.Equal(t, test.value, tok.Value, test.input)
+			assert.Equal(t, StringKind, tok.Kind, test.input)
+			assert.Equal(t, strings.TrimSpace(test.input), tok.Raw, test.input)
+		}
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+		} else {
+			assert.Nil(t, err, test.input)
 		}
 	}
 }
 
-func TestToken_lexIdentifier(t *testing.T) {
+func TestToken_lexDollarString(t *testing.T) {
 	tests := []struct {
-		Identifier bool
-		input      string
-		value      string
+		string bool
+		input  string
+		value  string
+		end    Location
+		err    string
 	}{
 		{
-			Identifier: true,
-			input:      "a",
-			value:      "a",
-		},
-		{
-			Identifier: true,
-			input:      "abc",
-			value:      "abc",
+			string: true,
+			input:  "$$abc$$",
+			value:  "abc",
+			end:    Location{Col: 7},
 		},
 		{
-			Identifier: true,
-			input:      "abc ",
-			value:      "abc",
+			string: true,
+			input:  "$$it's$$ ",
+			value:  "it's",
+			end:    Location{Col: 8},
 		},
 		{
-			Identifier: true,
-			input:      `" abc "`,
-			value:      ` abc `,
+			string: true,
+			input:  "$body$ a $$ b\nc $body$",
+			value:  " a $$ b\nc ",
+			end:    Location{Line: 1, Col: 8},
 		},
 		{
-			Identifier: true,
-			input:      "a9$",
-			value:      "a9$",
+			string: true,
+			input:  "$_t1$$_t1$",
+			value:  "",
+			end:    Location{Col: 10},
 		},
+		// false tests
 		{
-			Identifier: true,
-			input:      "userName",
-			value:      "username",
+			string: false,
+			input:  "$1",
 		},
 		{
-			Identifier: true,
-			input:      `"userName"`,
-			value:      "userName",
+			string: false,
+			input:  "$1$",
 		},
-		// false tests
 		{
-			Identifier: false,
-			input:      `"`,
+			string: false,
+			input:  "$a",
 		},
 		{
-			Identifier: false,
-			input:      "_sadsfa",
+			string: false,
+			input:  "$a b$",
 		},
 		{
-			Identifier: false,
-			input:      "9sadsfa",
+			string: false,
+			input:  "$$abc",
+			err:    "unterminated dollar-quoted string starting at line 1 col 1, expected closing $$",
 		},
 		{
-			Identifier: false,
-			input:      " abc",
+			string: false,
+			input:  "$a$ abc $b$",
+			err:    "unterminated dollar-quoted string starting at line 1 col 1, expected closing $a$",
 		},
 	}
 
 	for _, test := range tests {
-		tok, _, ok := lexIdentifier(test.input, cursor{})
-		assert.Equal(t, test.Identifier, ok, test.input)
+		tok, cur, ok, err := lexDollarString(test.input, cursor{})
+		assert.Equal(t, test.string, ok, test.input)
 		if ok {
 			assert.Equal(t, test.value, tok.Value, test.input)
+			assert.Equal(t, StringKind, tok.Kind, test.input)
+			assert.Equal(t, test.end, cur.loc, test.input)
+			assert.Equal(t, strings.TrimSpace(test.input), tok.Raw, test.input)
+		}
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+		} else {
+			assert.Nil(t, err, test.input)
 		}
 	}
 }
 
-func TestToken_lexKeyword(t *testing.T) {
+func TestToken_lexSymbol(t *testing.T) {
 	tests := []struct {
-		keyword bool
-		value   string
+		symbol bool
+		value  string
+		want   string
 	}{
 		{
-			keyword: true,
-			value:   "select ",
+			symbol: true,
+			value:  "= ",
 		},
 		{
-			keyword: true,
-			value:   "from",
+			symbol: true,
+			value:  "||",
 		},
 		{
-			keyword: true,
-			value:   "as",
+			symbol: true,
+			value:  "<>",
 		},
 		{
-			keyword: true,
-			value:   "SELECT",
+			symbol: true,
+			value:  "!=",
+			want:   "<>",
 		},
 		{
-			keyword: true,
-			value:   "into",
+			symbol: true,
+			value:  "<",
 		},
-		// false tests
 		{
-			keyword: false,
-			value:   " into",
+			symbol: true,
+			value:  "> 1",
+			want:   ">",
 		},
 		{
-			keyword: false,
-			value:   "flubbrety",
+			symbol: true,
+			value:  "<=",
 		},
-	}
-
-	for _, test := range tests {
-		tok, _, ok := lexKeyword(test.value, cursor{})
-		assert.Equal(t, test.keyword, ok, test.value)
-		if ok {
-			test.value = strings.TrimSpace(test.value)
-			assert.Equal(t, strings.ToLower(test.value), tok.Value, test.value)
-		}
-	}
-}
-
-func TestLex(t *testing.T) {
-	tests := []struct {
-		input  string
-		Tokens []Token
-		err    error
-	}{
 		{
-			input: "select a",
-			Tokens: []Token{
-				{
-					Loc:   Location{Col: 0, Line: 0},
-					Value: string(SelectKeyword),
-					Kind:  KeywordKind,
-				},
-				{
+			symbol: true,
+			value:  ">=1",
+			want:   ">=",
+		},
+		{
+			symbol: true,
+			value:  "<=>",
+			want:   "<=",
+		},
+		{
+			symbol: true,
+			value:  "+",
+		},
+		{
+			symbol: true,
+			value:  "-1",
+			want:   "-",
+		},
+		{
+			symbol: true,
+			value:  "/",
+		},
+		{
+			symbol: true,
+			value:  "%",
+		},
+		{
+			symbol: true,
+			value:  "::int",
+			want:   "::",
+		},
+		{
+			symbol: true,
+			value:  "->'a'",
+			want:   "->",
+		},
+		{
+			symbol: true,
+			value:  "->>'a'",
+			want:   "->>",
+		},
+		{
+			symbol: true,
+			value:  "->>>",
+			want:   "->>",
+		},
+		{
+			symbol: true,
+			value:  "- >",
+			want:   "-",
+		},
+		{
+			symbol: true,
+			value:  "||'b'",
+			want:   "||",
+		},
+		{
+			symbol: true,
+			value:  ".id",
+			want:   ".",
+		},
+		// false tests
+		{
+			symbol: false,
+			value:  "!",
+		},
+		{
+			symbol: false,
+			value:  "|",
+		},
+		{
+			symbol: false,
+			value:  ":a",
+		},
+		{
+			symbol: false,
+			value:  ".5",
+		},
+	}
+
+	for _, test := range tests {
+		tok, cur, ok, _ := lexSymbol(test.value, cursor{})
+		assert.Equal(t, test.symbol, ok, test.value)
+		if ok {
+			if test.want == "" {
+				test.want = strings.TrimSpace(test.value)
+			}
+			assert.Equal(t, test.want, tok.Value, test.value)
+			assert.Equal(t, uint(len(tok.Value)), cur.pointer, test.value)
+		}
+	}
+}
+
+func TestToken_lexIdentifier(t *testing.T) {
+	tests := []struct {
+		Identifier bool
+		input      string
+		value      string
+	}{
+		{
+			Identifier: true,
+			input:      "a",
+			value:      "a",
+		},
+		{
+			Identifier: true,
+			input:      "abc",
+			value:      "abc",
+		},
+		{
+			Identifier: true,
+			input:      "abc ",
+			value:      "abc",
+		},
+		{
+			Identifier: true,
+			input:      `" abc "`,
+			value:      ` abc `,
+		},
+		{
+			Identifier: true,
+			input:      "a9$",
+			value:      "a9$",
+		},
+		{
+			Identifier: true,
+			input:      "userName",
+			value:      "username",
+		},
+		{
+			Identifier: true,
+			input:      `"userName"`,
+			value:      "userName",
+		},
+		{
+			Identifier: true,
+			input:      `"a""b"`,
+			value:      `a"b`,
+		},
+		{
+			Identifier: true,
+			input:      `"ab"""`,
+			value:      `ab"`,
+		},
+		{
+			Identifier: true,
+			input:      "名前",
+			value:      "名前",
+		},
+		{
+			Identifier: true,
+			input:      "Café_2 ",
+			value:      "café_2",
+		},
+		{
+			Identifier: true,
+			input:      "ÉCOLE",
+			value:      "École",
+		},
+		// false tests
+		{
+			Identifier: false,
+			input:      `"`,
+		},
+		{
+			Identifier: false,
+			input:      "_sadsfa",
+		},
+		{
+			Identifier: false,
+			input:      "9sadsfa",
+		},
+		{
+			Identifier: false,
+			input:      " abc",
+		},
+		{
+			Identifier: false,
+			input:      "１abc",
+		},
+		{
+			Identifier: false,
+			input:      "\xff",
+		},
+	}
+
+	for _, test := range tests {
+		tok, _, ok, _ := lexIdentifier(test.input, cursor{})
+		assert.Equal(t, test.Identifier, ok, test.input)
+		if ok {
+			assert.Equal(t, test.value, tok.Value, test.input)
+			assert.Equal(t, IdentifierKind, tok.Kind, test.input)
+		}
+	}
+}
+
+func TestToken_lexComment(t *testing.T) {
+	tests := []struct {
+		comment bool
+		value   string
+		loc     Location
+		err     string
+	}{
+		{
+			comment: true,
+			value:   "-- abc",
+			loc:     Location{Line: 0, Col: 6},
+		},
+		{
+			comment: true,
+			value:   "--",
+			loc:     Location{Line: 0, Col: 2},
+		},
+		{
+			comment: true,
+			value:   "-- abc\nselect",
+			loc:     Location{Line: 0, Col: 6},
+		},
+		{
+			comment: true,
+			value:   "/* abc */",
+			loc:     Location{Line: 0, Col: 9},
+		},
+		{
+			comment: true,
+			value:   "/* a\n b\n*/ select",
+			loc:     Location{Line: 2, Col: 2},
+		},
+		{
+			comment: true,
+			value:   "/**/",
+			loc:     Location{Line: 0, Col: 4},
+		},
+		// false tests
+		{
+			comment: false,
+			value:   "-",
+		},
+		{
+			comment: false,
+			value:   "/",
+		},
+		{
+			comment: false,
+			value:   "/* abc",
+			err:     "unterminated block comment starting at line 1 col 1",
+		},
+		{
+			comment: false,
+			value:   "/* a /* b */ */",
+			err:     "nested block comments are not supported, at line 1 col 6",
+		},
+		{
+			comment: false,
+			value:   " -- abc",
+		},
+	}
+
+	for _, test := range tests {
+		tok, cur, ok, err := lexComment(test.value, cursor{})
+		assert.Equal(t, test.comment, ok, test.value)
+		if ok {
+			assert.Equal(t, CommentKind, tok.Kind, test.value)
+			assert.Equal(t, test.value[:cur.pointer], tok.Value, test.value)
+		}
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.value)
+		} else {
+			assert.Nil(t, err, test.value)
+		}
+		if ok {
+			assert.Equal(t, test.loc, cur.loc, test.value)
+		}
+	}
+}
+
+func TestToken_lexParameter(t *testing.T) {
+	tests := []struct {
+		parameter bool
+		input     string
+		value     string
+		err       string
+	}{
+		{
+			parameter: true,
+			input:     "$1",
+			value:     "$1",
+		},
+		{
+			parameter: true,
+			input:     "$12)",
+			value:     "$12",
+		},
+		{
+			parameter: true,
+			input:     "?",
+			value:     "?",
+		},
+		{
+			parameter: true,
+			input:     "?, ?",
+			value:     "?",
+		},
+		{
+			parameter: true,
+			input:     ":user_id",
+			value:     "user_id",
+		},
+		{
+			parameter: true,
+			input:     ":UserId)",
+			value:     "UserId",
+		},
+		// false tests
+		{
+			parameter: false,
+			input:     "$a",
+		},
+		{
+			parameter: false,
+			input:     "::int",
+		},
+		{
+			parameter: false,
+			input:     ":",
+			err:       "expected parameter name after ':' at line 1 col 1",
+		},
+		{
+			parameter: false,
+			input:     ": name",
+			err:       "expected parameter name after ':' at line 1 col 1",
+		},
+		{
+			parameter: false,
+			input:     ":1",
+			err:       "expected parameter name after ':' at line 1 col 1",
+		},
+		{
+			parameter: false,
+			input:     "$",
+		},
+		{
+			parameter: false,
+			input:     "1",
+		},
+		{
+			parameter: false,
+			input:     "$0",
+			err:       "parameter numbers start at $1, found $0 at line 1 col 1",
+		},
+	}
+
+	for _, test := range tests {
+		tok, _, ok, err := lexParameter(test.input, cursor{})
+		assert.Equal(t, test.parameter, ok, test.input)
+		if ok {
+			assert.Equal(t, test.value, tok.Value, test.input)
+			if test.input[0] == ':' {
+				assert.Equal(t, NamedParameterKind, tok.Kind, test.input)
+			} else {
+				assert.Equal(t, ParameterKind, tok.Kind, test.input)
+			}
+		}
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+		} else {
+			assert.Nil(t, err, test.input)
+		}
+	}
+}
+
+func TestToken_lexBool(t *testing.T) {
+	tests := []struct {
+		bool  bool
+		input string
+		value string
+	}{
+		{
+			bool:  true,
+			input: "true",
+			value: "true",
+		},
+		{
+			bool:  true,
+			input: "FALSE ",
+			value: "false",
+		},
+		{
+			bool:  true,
+			input: "True)",
+			value: "true",
+		},
+		// false tests
+		{
+			bool:  false,
+			input: "truevalue",
+		},
+		{
+			bool:  false,
+			input: "false_1",
+		},
+		{
+			bool:  false,
+			input: "tru",
+		},
+		{
+			bool:  false,
+			input: " true",
+		},
+	}
+
+	for _, test := range tests {
+		tok, _, ok, _ := lexBool(test.input, cursor{})
+		assert.Equal(t, test.bool, ok, test.input)
+		if ok {
+			assert.Equal(t, test.value, tok.Value, test.input)
+			assert.Equal(t, BoolKind, tok.Kind, test.input)
+		}
+	}
+}
+
+func TestToken_lexKeyword(t *testing.T) {
+	tests := []struct {
+		keyword bool
+		value   string
+	}{
+		{
+			keyword: true,
+			value:   "select ",
+		},
+		{
+			keyword: true,
+			value:   "from",
+		},
+		{
+			keyword: true,
+			value:   "as",
+		},
+		{
+			keyword: true,
+			value:   "SELECT",
+		},
+		{
+			keyword: true,
+			value:   "into",
+		},
+		{
+			keyword: true,
+			value:   "and",
+		},
+		{
+			keyword: true,
+			value:   "OR ",
+		},
+		{
+			keyword: true,
+			value:   "not",
+		},
+		{
+			keyword: true,
+			value:   "NULL",
+		},
+		{
+			keyword: true,
+			value:   "Null",
+		},
+		{
+			keyword: true,
+			value:   "int",
+		},
+		{
+			keyword: true,
+			value:   "order",
+		},
+		{
+			keyword: true,
+			value:   "BY ",
+		},
+		{
+			keyword: true,
+			value:   "group",
+		},
+		{
+			keyword: true,
+			value:   "having",
+		},
+		{
+			keyword: true,
+			value:   "limit",
+		},
+		{
+			keyword: true,
+			value:   "offset",
+		},
+		{
+			keyword: true,
+			value:   "asc",
+		},
+		{
+			keyword: true,
+			value:   "desc",
+		},
+		{
+			keyword: true,
+			value:   "join",
+		},
+		{
+			keyword: true,
+			value:   "Inner",
+		},
+		{
+			keyword: true,
+			value:   "left",
+		},
+		{
+			keyword: true,
+			value:   "RIGHT",
+		},
+		{
+			keyword: true,
+			value:   "full",
+		},
+		{
+			keyword: true,
+			value:   "outer",
+		},
+		{
+			keyword: true,
+			value:   "union",
+		},
+		{
+			keyword: true,
+			value:   "INTERSECT",
+		},
+		{
+			keyword: true,
+			value:   "except",
+		},
+		{
+			keyword: true,
+			value:   "All",
+		},
+		{
+			keyword: true,
+			value:   "truncate",
+		},
+		{
+			keyword: true,
+			value:   "BEGIN",
+		},
+		{
+			keyword: true,
+			value:   "commit",
+		},
+		{
+			keyword: true,
+			value:   "Rollback",
+		},
+		{
+			keyword: true,
+			value:   "transaction",
+		},
+		{
+			keyword: true,
+			value:   "work",
+		},
+		{
+			keyword: true,
+			value:   "explain",
+		},
+		{
+			keyword: true,
+			value:   "ANALYZE",
+		},
+		{
+			keyword: true,
+			value:   "show",
+		},
+		{
+			keyword: true,
+			value:   "Tables",
+		},
+		{
+			keyword: true,
+			value:   "describe",
+		},
+		{
+			keyword: true,
+			value:   "VIEW",
+		},
+		{
+			keyword: true,
+			value:   "replace",
+		},
+		{
+			keyword: true,
+			value:   "drop",
+		},
+		{
+			keyword: true,
+			value:   "boolean",
+		},
+		{
+			keyword: true,
+			value:   "REAL",
+		},
+		{
+			keyword: true,
+			value:   "float",
+		},
+		{
+			keyword: true,
+			value:   "numeric",
+		},
+		{
+			keyword: true,
+			value:   "Decimal",
+		},
+		{
+			keyword: true,
+			value:   "varchar",
+		},
+		{
+			keyword: true,
+			value:   "char",
+		},
+		{
+			keyword: true,
+			value:   "bigint",
+		},
+		{
+			keyword: true,
+			value:   "date",
+		},
+		{
+			keyword: true,
+			value:   "Time",
+		},
+		{
+			keyword: true,
+			value:   "timestamp",
+		},
+		{
+			keyword: true,
+			value:   "left",
+		},
+		{
+			keyword: true,
+			value:   "right",
+		},
+		{
+			keyword: true,
+			value:   "full",
+		},
+		{
+			keyword: true,
+			value:   "outer",
+		},
+		{
+			keyword: true,
+			value:   "cross",
+		},
+		{
+			keyword: true,
+			value:   "on",
+		},
+		{
+			keyword: true,
+			value:   "USING",
+		},
+		{
+			keyword: true,
+			value:   "if",
+		},
+		{
+			keyword: true,
+			value:   "Exists",
+		},
+		{
+			keyword: true,
+			value:   "PRIMARY",
+		},
+		{
+			keyword: true,
+			value:   "key",
+		},
+		{
+			keyword: true,
+			value:   "unique",
+		},
+		{
+			keyword: true,
+			value:   "Default",
+		},
+		{
+			keyword: true,
+			value:   "check",
+		},
+		{
+			keyword: true,
+			value:   "foreign",
+		},
+		{
+			keyword: true,
+			value:   "REFERENCES",
+		},
+		{
+			keyword: true,
+			value:   "delete",
+		},
+		{
+			keyword: true,
+			value:   "UPDATE",
+		},
+		{
+			keyword: true,
+			value:   "set",
+		},
+		{
+			keyword: true,
+			value:   "index",
+		},
+		{
+			keyword: true,
+			value:   "DISTINCT",
+		},
+		{
+			keyword: true,
+			value:   "case",
+		},
+		{
+			keyword: true,
+			value:   "When",
+		},
+		{
+			keyword: true,
+			value:   "then",
+		},
+		{
+			keyword: true,
+			value:   "ELSE",
+		},
+		{
+			keyword: true,
+			value:   "end",
+		},
+		{
+			keyword: true,
+			value:   "Cast",
+		},
+		{
+			keyword: true,
+			value:   "IN",
+		},
+		{
+			keyword: true,
+			value:   "Between",
+		},
+		{
+			keyword: true,
+			value:   "like",
+		},
+		{
+			keyword: true,
+			value:   "ESCAPE",
+		},
+		{
+			keyword: true,
+			value:   "is",
+		},
+		{
+			keyword: true,
+			value:   "EXISTS",
+		},
+		{
+			keyword: true,
+			value:   "Inner",
+		},
+		// false tests
+		{
+			keyword: false,
+			value:   "andy",
+		},
+		{
+			keyword: false,
+			value:   "nothing",
+		},
+		{
+			keyword: false,
+			value:   "online",
+		},
+		{
+			keyword: false,
+			value:   "username",
+		},
+		{
+			keyword: false,
+			value:   "usings",
+		},
+		{
+			keyword: false,
+			value:   "joined",
+		},
+		{
+			keyword: false,
+			value:   "iffy",
+		},
+		{
+			keyword: false,
+			value:   "existsx",
+		},
+		{
+			keyword: false,
+			value:   "keys",
+		},
+		{
+			keyword: false,
+			value:   "settings",
+		},
+		{
+			keyword: false,
+			value:   "indexes",
+		},
+		{
+			keyword: false,
+			value:   "ending",
+		},
+		{
+			keyword: false,
+			value:   "fuller",
+		},
+		{
+			keyword: false,
+			value:   "byte",
+		},
+		{
+			keyword: false,
+			value:   "ordering",
+		},
+		{
+			keyword: false,
+			value:   "ascii",
+		},
+		{
+			keyword: false,
+			value:   "description",
+		},
+		{
+			keyword: false,
+			value:   "limits",
+		},
+		{
+			keyword: false,
+			value:   "nullable",
+		},
+		{
+			keyword: false,
+			value:   " into",
+		},
+		{
+			keyword: false,
+			value:   "flubbrety",
+		},
+	}
+
+	for _, test := range tests {
+		tok, _, ok, _ := lexKeyword(test.value, cursor{})
+		assert.Equal(t, test.keyword, ok, test.value)
+		if ok {
+			test.value = strings.TrimSpace(test.value)
+			assert.Equal(t, strings.ToLower(test.value), tok.Value, test.value)
+		}
+	}
+}
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		input  string
+		Tokens []Token
+		err    error
+	}{
+		{
+			input: "select a",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(SelectKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 7, Line: 0},
+					Value: "a",
+					Kind:  IdentifierKind,
+				},
+			},
+		},
+		{
+			input: "select true",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(SelectKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 7, Line: 0},
+					Value: "true",
+					Kind:  BoolKind,
+				},
+			},
+		},
+		{
+			input: "select a -- trailing comment",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(SelectKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 7, Line: 0},
+					Value: "a",
+					Kind:  IdentifierKind,
+				},
+			},
+		},
+		{
+			input: "select a -- comment\nfrom b",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(SelectKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 7, Line: 0},
+					Value: "a",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 0, Line: 1},
+					Value: string(FromKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 5, Line: 1},
+					Value: "b",
+					Kind:  IdentifierKind,
+				},
+			},
+		},
+		{
+			input: "select /* multi\nline */ a",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(SelectKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 8, Line: 1},
+					Value: "a",
+					Kind:  IdentifierKind,
+				},
+			},
+		},
+		{
+			input: "select a /* never closed",
+			err:   errors.New("unterminated block comment starting at line 1 col 10"),
+		},
+		{
+			input: "where a<=1",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(WhereKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 6, Line: 0},
+					Value: "a",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 7, Line: 0},
+					Value: string(LteSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 9, Line: 0},
+					Value: "1",
+					Kind:  NumericKind,
+				},
+			},
+		},
+		{
+			input: "where a != b",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(WhereKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 6, Line: 0},
+					Value: "a",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 8, Line: 0},
+					Value: string(NeqSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 11, Line: 0},
+					Value: "b",
+					Kind:  IdentifierKind,
+				},
+			},
+		},
+		{
+			input: "select price * qty + tax from items",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(SelectKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 7, Line: 0},
+					Value: "price",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 13, Line: 0},
+					Value: string(AsteriskSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 15, Line: 0},
+					Value: "qty",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 19, Line: 0},
+					Value: string(PlusSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 21, Line: 0},
+					Value: "tax",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 25, Line: 0},
+					Value: string(FromKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 30, Line: 0},
+					Value: "items",
+					Kind:  IdentifierKind,
+				},
+			},
+		},
+		{
+			input: "a%b/-1--c",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: "a",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 1, Line: 0},
+					Value: string(ModuloSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 2, Line: 0},
+					Value: "b",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 3, Line: 0},
+					Value: string(SlashSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 4, Line: 0},
+					Value: string(MinusSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 5, Line: 0},
+					Value: "1",
+					Kind:  NumericKind,
+				},
+			},
+		},
+		{
+			input: "'a'||'b'",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: "a",
+					Kind:  StringKind,
+				},
+				{
+					Loc:   Location{Col: 3, Line: 0},
+					Value: string(ConcatSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 5, Line: 0},
+					Value: "b",
+					Kind:  StringKind,
+				},
+			},
+		},
+		{
+			input: "'a' | 'b'",
+			err:   errors.New("unexpected '|' at line 1 col 5, did you mean '||'"),
+		},
+		{
+			input: "select truevalue",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(SelectKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 7, Line: 0},
+					Value: "truevalue",
+					Kind:  IdentifierKind,
+				},
+			},
+		},
+		{
+			input: "where andy and not nothing or a = NULL",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(WhereKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 6, Line: 0},
+					Value: "andy",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 11, Line: 0},
+					Value: string(AndKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 15, Line: 0},
+					Value: string(NotKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 19, Line: 0},
+					Value: "nothing",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 27, Line: 0},
+					Value: string(OrKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 30, Line: 0},
+					Value: "a",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 32, Line: 0},
+					Value: string(EqSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 34, Line: 0},
+					Value: string(NullKeyword),
+					Kind:  KeywordKind,
+				},
+			},
+		},
+		{
+			input: "0x1F",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: "0x1F",
+					Kind:  NumericKind,
+				},
+			},
+		},
+		{
+			input: "select 0x",
+			err:   errors.New("expected hexadecimal digits after 'x' at line 1 col 9"),
+		},
+		{
+			input: "where a = $1 and b = ?",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(WhereKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 6, Line: 0},
+					Value: "a",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 8, Line: 0},
+					Value: string(EqSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 10, Line: 0},
+					Value: "$1",
+					Kind:  ParameterKind,
+				},
+				{
+					Loc:   Location{Col: 13, Line: 0},
+					Value: string(AndKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 17, Line: 0},
+					Value: "b",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 19, Line: 0},
+					Value: string(EqSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 21, Line: 0},
+					Value: "?",
+					Kind:  ParameterKind,
+				},
+			},
+		},
+		{
+			input: "where id = :id",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(WhereKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 6, Line: 0},
+					Value: "id",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 9, Line: 0},
+					Value: string(EqSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 11, Line: 0},
+					Value: "id",
+					Kind:  NamedParameterKind,
+				},
+			},
+		},
+		{
+			input: "where id = : id",
+			err:   errors.New("expected parameter name after ':' at line 1 col 12"),
+		},
+		{
+			input: "select $0",
+			err:   errors.New("parameter numbers start at $1, found $0 at line 1 col 8"),
+		},
+		{
+			input: `select "Name", 'Name' from "Users"`,
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(SelectKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 7, Line: 0},
+					Value: "Name",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 13, Line: 0},
+					Value: string(CommaSymbol),
+					Kind:  SymbolKind,
+				},
+				{
+					Loc:   Location{Col: 15, Line: 0},
+					Value: "Name",
+					Kind:  StringKind,
+				},
+				{
+					Loc:   Location{Col: 22, Line: 0},
+					Value: string(FromKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 27, Line: 0},
+					Value: "Users",
+					Kind:  IdentifierKind,
+				},
+			},
+		},
+		{
+			input: "select 'abc",
+			err:   errors.New("unterminated string literal starting at line 1 col 8"),
+		},
+		{
+			input: "select a from \"users",
+			err:   errors.New("unterminated quoted identifier starting at line 1 col 15"),
+		},
+		{
+			input: "select * from t order by id limit 10",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(SelectKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 7, Line: 0},
+					Value: string(AsteriskSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 9, Line: 0},
+					Value: string(FromKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 14, Line: 0},
+					Value: "t",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 16, Line: 0},
+					Value: string(OrderKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 22, Line: 0},
+					Value: string(ByKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 25, Line: 0},
+					Value: "id",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 28, Line: 0},
+					Value: string(LimitKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 34, Line: 0},
+					Value: "10",
+					Kind:  NumericKind,
+				},
+			},
+		},
+		{
+			input: "a->>'b'",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: "a",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 1, Line: 0},
+					Value: string(JSONExtractTextSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 4, Line: 0},
+					Value: "b",
+					Kind:  StringKind,
+				},
+			},
+		},
+		{
+			input: "data -> 'key' -> 1",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: "data",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 5, Line: 0},
+					Value: string(JSONExtractSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 8, Line: 0},
+					Value: "key",
+					Kind:  StringKind,
+				},
+				{
+					Loc:   Location{Col: 14, Line: 0},
+					Value: string(JSONExtractSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 17, Line: 0},
+					Value: "1",
+					Kind:  NumericKind,
+				},
+			},
+		},
+		{
+			input: "select 1",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(SelectKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 7, Line: 0},
+					Value: "1",
+					Kind:  NumericKind,
+				},
+			},
+			err: nil,
+		},
+		{
+			input: "select 'foo' || 'bar';",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(SelectKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 7, Line: 0},
+					Value: "foo",
+					Kind:  StringKind,
+				},
+				{
+					Loc:   Location{Col: 13, Line: 0},
+					Value: string(ConcatSymbol),
+					Kind:  OperatorKind,
+				},
+				{
+					Loc:   Location{Col: 16, Line: 0},
+					Value: "bar",
+					Kind:  StringKind,
+				},
+				{
+					Loc:   Location{Col: 21, Line: 0},
+					Value: string(SemicolonSymbol),
+					Kind:  SymbolKind,
+				},
+			},
+			err: nil,
+		},
+		{
+			input: "CREATE TABLE u (id INT, name TEXT)",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(CreateKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 7, Line: 0},
+					Value: string(TableKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 13, Line: 0},
+					Value: "u",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 15, Line: 0},
+					Value: "(",
+					Kind:  SymbolKind,
+				},
+				{
+					Loc:   Location{Col: 16, Line: 0},
+					Value: "id",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 19, Line: 0},
+					Value: "int",
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 22, Line: 0},
+					Value: ",",
+					Kind:  SymbolKind,
+				},
+				{
+					Loc:   Location{Col: 24, Line: 0},
+					Value: "name",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 29, Line: 0},
+					Value: "text",
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 33, Line: 0},
+					Value: ")",
+					Kind:  SymbolKind,
+				},
+			},
+		},
+		{
+			input: "insert into users Values (105, 233)",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(InsertKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 7, Line: 0},
+					Value: string(IntoKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 12, Line: 0},
+					Value: "users",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 18, Line: 0},
+					Value: string(ValuesKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 25, Line: 0},
+					Value: "(",
+					Kind:  SymbolKind,
+				},
+				{
+					Loc:   Location{Col: 26, Line: 0},
+					Value: "105",
+					Kind:  NumericKind,
+				},
+				{
+					Loc:   Location{Col: 29, Line: 0},
+					Value: ",",
+					Kind:  SymbolKind,
+				},
+				{
+					Loc:   Location{Col: 31, Line: 0},
+					Value: "233",
+					Kind:  NumericKind,
+				},
+				{
+					Loc:   Location{Col: 34, Line: 0},
+					Value: ")",
+					Kind:  SymbolKind,
+				},
+			},
+			err: nil,
+		},
+		{
+			input: "SELECT id FROM users;",
+			Tokens: []Token{
+				{
+					Loc:   Location{Col: 0, Line: 0},
+					Value: string(SelectKeyword),
+					Kind:  KeywordKind,
+				},
+				{
 					Loc:   Location{Col: 7, Line: 0},
-					Value: "a",
+					Value: "id",
+					Kind:  IdentifierKind,
+				},
+				{
+					Loc:   Location{Col: 10, Line: 0},
+					Value: string(FromKeyword),
+					Kind:  KeywordKind,
+				},
+				{
+					Loc:   Location{Col: 15, Line: 0},
+					Value: "users",
 					Kind:  IdentifierKind,
 				},
+				{
+					Loc:   Location{Col: 20, Line: 0},
+					Value: ";",
+					Kind:  SymbolKind,
+				},
 			},
+			err: nil,
+		},
+	}
+
+	for _, test := range tests {
+		tokens, err := lex(test.input)
+		if test.err != nil {
+			assert.EqualError(t, err, test.err.Error(), test.input)
+			assert.IsType(t, &LexError{}, err, test.input)
+		} else {
+			assert.Nil(t, err, test.input)
+		}
+		assert.Equal(t, len(test.Tokens), len(tokens), test.input)
+
+		for i, tok := range tokens {
+			assert.Equal(t, test.Tokens[i].Value, tok.Value, test.input)
+			assert.Equal(t, test.Tokens[i].Kind, tok.Kind, test.input)
+			assert.Equal(t, test.Tokens[i].Loc, tok.Loc, test.input)
+		}
+	}
+}
+
+func TestLex_join(t *testing.T) {
+	source := "select name from users left outer join online on id = online_id inner join t using (username) cross join s full join r on 1"
+	want := []struct {
+		value string
+		kind  TokenKind
+	}{
+		{"select", KeywordKind},
+		{"name", IdentifierKind},
+		{"from", KeywordKind},
+		{"users", IdentifierKind},
+		{"left", KeywordKind},
+		{"outer", KeywordKind},
+		{"join", KeywordKind},
+		{"online", IdentifierKind},
+		{"on", KeywordKind},
+		{"id", IdentifierKind},
+		{"=", OperatorKind},
+		{"online_id", IdentifierKind},
+		{"inner", KeywordKind},
+		{"join", KeywordKind},
+		{"t", IdentifierKind},
+		{"using", KeywordKind},
+		{"(", SymbolKind},
+		{"username", IdentifierKind},
+		{")", SymbolKind},
+		{"cross", KeywordKind},
+		{"join", KeywordKind},
+		{"s", IdentifierKind},
+		{"full", KeywordKind},
+		{"join", KeywordKind},
+		{"r", IdentifierKind},
+		{"on", KeywordKind},
+		{"1", NumericKind},
+	}
+
+	tokens, err := lex(source)
+	assert.Nil(t, err)
+	assert.Equal(t, len(want), len(tokens))
+	for i, tok := range tokens {
+		if i >= len(want) {
+			break
+		}
+		assert.Equal(t, want[i].value, tok.Value, source)
+		assert.Equal(t, want[i].kind, tok.Kind, tok.Value)
+	}
+}
+
+func TestLex_operatorKind(t *testing.T) {
+	tests := []struct {
+		symbol string
+		kind   TokenKind
+	}{
+		{";", SymbolKind},
+		{",", SymbolKind},
+		{"(", SymbolKind},
+		{")", SymbolKind},
+		{"=", OperatorKind},
+		{"<", OperatorKind},
+		{">", OperatorKind},
+		{"<=", OperatorKind},
+		{">=", OperatorKind},
+		{"<>", OperatorKind},
+		{"!=", OperatorKind},
+		{"+", OperatorKind},
+		{"-", OperatorKind},
+		{"*", OperatorKind},
+		{"/", OperatorKind},
+		{"%", OperatorKind},
+		{"||", OperatorKind},
+		{"::", OperatorKind},
+		{"->", OperatorKind},
+		{"->>", OperatorKind},
+	}
+
+	for _, test := range tests {
+		tok, _, ok, err := lexSymbol(test.symbol, cursor{})
+		assert.Nil(t, err, test.symbol)
+		assert.True(t, ok, test.symbol)
+		assert.Equal(t, test.kind, tok.Kind, test.symbol)
+	}
+
+	// 投影列里的 * 也是 OperatorKind，由语法分析器按位置区分
+	tokens, err := lex("select *, count(*) from t")
+	assert.Nil(t, err)
+	assert.Equal(t, OperatorKind, tokens[1].Kind)
+	assert.Equal(t, string(AsteriskSymbol), tokens[1].Value)
+	assert.Equal(t, OperatorKind, tokens[5].Kind)
+	assert.Equal(t, string(AsteriskSymbol), tokens[5].Value)
+}
+
+func TestLexAll(t *testing.T) {
+	source := "select a | b;\nselect 0x, c;\nselect #d"
+	tokens, errs := LexAll(source)
+
+	var values []string
+	for _, tok := range tokens {
+		values = append(values, tok.Value)
+	}
+	assert.Equal(t, []string{"select", "a", "b", ";", "select", "c", ";", "select"}, values)
+
+	assert.Equal(t, 3, len(errs))
+	want := []LexError{
+		{
+			Loc:     Location{Line: 0, Col: 9},
+			Snippet: "|",
+			Msg:     "unexpected '|' at line 1 col 10, did you mean '||'",
+		},
+		{
+			Loc:     Location{Line: 1, Col: 7},
+			Snippet: "0x,",
+			Msg:     "expected hexadecimal digits after 'x' at line 2 col 9",
+		},
+		{
+			Loc:     Location{Line: 2, Col: 7},
+			Snippet: "#d",
+			Msg:     "Unable to lex token after keyword 'select' at 3:8",
+		},
+	}
+	for i, err := range errs {
+		var lexErr *LexError
+		assert.True(t, errors.As(err, &lexErr))
+		if i < len(want) && lexErr != nil {
+			assert.Equal(t, want[i], *lexErr)
+		}
+	}
+
+	// 没有错误时和 lex 的结果一致
+	tokens, errs = LexAll("select a from t")
+	assert.Nil(t, errs)
+	expected, err := lex("select a from t")
+	assert.Nil(t, err)
+	assert.Equal(t, expected, tokens)
+}
+
+func TestTokenKind_String(t *testing.T) {
+	assert.Equal(t, "keyword", KeywordKind.String())
+	assert.Equal(t, "symbol", SymbolKind.String())
+	assert.Equal(t, "identifier", IdentifierKind.String())
+	assert.Equal(t, "string", StringKind.String())
+	assert.Equal(t, "numeric", NumericKind.String())
+	assert.Equal(t, "bool", BoolKind.String())
+	assert.Equal(t, "operator", OperatorKind.String())
+	assert.Equal(t, "TokenKind(99)", TokenKind(99).String())
+	assert.Equal(t, "select", SelectKeyword.String())
+	assert.Equal(t, "<>", NeqSymbol.String())
+}
+
+func TestDebugTokens(t *testing.T) {
+	tokens, err := lex("select 'a'\nfrom t;")
+	assert.Nil(t, err)
+	assert.Equal(t, `keyword("select") @1:1`, tokens[0].String())
+	assert.Equal(t, `keyword("select") @1:1
+string("a") @1:8
+keyword("from") @2:1
+identifier("t") @2:6
+symbol(";") @2:7
+`, DebugTokens(tokens))
+}
+
+func TestLex_allocsPerToken(t *testing.T) {
+	source := "SELECT id, Name FROM users WHERE id >= 10 AND name <> 'it''s' -- x\n;"
+	tokens, err := lex(source)
+	assert.Nil(t, err)
+
+	// 每个 token 只分配自身，另外还有结果切片
+	allocs := testing.AllocsPerRun(10, func() {
+		lex(source)
+	})
+	assert.LessOrEqual(t, allocs, float64(2*len(tokens)+2))
+}
+
+func TestLex_keywordBoundaries(t *testing.T) {
+	tests := []struct {
+		input  string
+		tokens []string
+		kinds  []TokenKind
+	}{
+		{"selection", []string{"selection"}, []TokenKind{IdentifierKind}},
+		{"fromage", []string{"fromage"}, []TokenKind{IdentifierKind}},
+		{"integer", []string{"integer"}, []TokenKind{IdentifierKind}},
+		{"values1", []string{"values1"}, []TokenKind{IdentifierKind}},
+		{"into_table", []string{"into_table"}, []TokenKind{IdentifierKind}},
+		{"select$1", []string{"select$1"}, []TokenKind{IdentifierKind}},
+		{"fromé", []string{"fromé"}, []TokenKind{IdentifierKind}},
+		{"true$", []string{"true$"}, []TokenKind{IdentifierKind}},
+		{"select(1)", []string{"select", "(", "1", ")"}, []TokenKind{KeywordKind, SymbolKind, NumericKind, SymbolKind}},
+		{"from;", []string{"from", ";"}, []TokenKind{KeywordKind, SymbolKind}},
+	}
+
+	for _, test := range tests {
+		tokens, err := lex(test.input)
+		assert.Nil(t, err, test.input)
+		assert.Equal(t, len(test.tokens), len(tokens), test.input)
+		for i, tok := range tokens {
+			if i >= len(test.tokens) {
+				break
+			}
+			assert.Equal(t, test.tokens[i], tok.Value, test.input)
+			assert.Equal(t, test.kinds[i], tok.Kind, test.input)
+		}
+	}
+
+	tok, _, ok, err := lexNamedParameter(":naïve_x rest", cursor{})
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "naïve_x", tok.Value)
+	assert.Equal(t, Location{Col: 8}, tok.End)
+}
+
+func TestLex_malformedNumbers(t *testing.T) {
+	tests := []struct {
+		input  string
+		values []string
+		kinds  []TokenKind
+	}{
+		{"1.2.3", []string{"1.2", ".3"}, []TokenKind{NumericKind, NumericKind}},
+		{"1..2", []string{"1.", ".2"}, []TokenKind{NumericKind, NumericKind}},
+		{".5", []string{".5"}, []TokenKind{NumericKind}},
+		{"5.", []string{"5."}, []TokenKind{NumericKind}},
+		{"5.e3", []string{"5.e3"}, []TokenKind{NumericKind}},
+		{"select 5., 6", []string{"select", "5.", ",", "6"}, []TokenKind{KeywordKind, NumericKind, SymbolKind, NumericKind}},
+		{"1e3e4", []string{"1e3", "e4"}, []TokenKind{NumericKind, IdentifierKind}},
+	}
+
+	for _, test := range tests {
+		tokens, err := lex(test.input)
+		assert.Nil(t, err, test.input)
+		assert.Equal(t, len(test.values), len(tokens), test.input)
+		for i, tok := range tokens {
+			if i >= len(test.values) {
+				break
+			}
+			assert.Equal(t, test.values[i], tok.Value, test.input)
+			assert.Equal(t, test.kinds[i], tok.Kind, test.input)
+		}
+	}
+
+	// 单独的 . 不是数字，而是限定列名用的符号
+	tokens, err := lex("select .")
+	assert.Nil(t, err)
+	assert.Equal(t, SymbolKind, tokens[1].Kind)
+}
+
+func TestLex_signedNumeric(t *testing.T) {
+	tests := []struct {
+		input  string
+		values []string
+		kinds  []TokenKind
+	}{
+		{
+			input:  "- 5",
+			values: []string{"-", "5"},
+			kinds:  []TokenKind{OperatorKind, NumericKind},
+		},
+		{
+			input:  "-5",
+			values: []string{"-", "5"},
+			kinds:  []TokenKind{OperatorKind, NumericKind},
+		},
+		{
+			input:  "(-5)",
+			values: []string{"(", "-", "5", ")"},
+			kinds:  []TokenKind{SymbolKind, OperatorKind, NumericKind, SymbolKind},
+		},
+		{
+			input:  "1-2",
+			values: []string{"1", "-", "2"},
+			kinds:  []TokenKind{NumericKind, OperatorKind, NumericKind},
+		},
+		{
+			input:  "+3.2",
+			values: []string{"+", "3.2"},
+			kinds:  []TokenKind{OperatorKind, NumericKind},
+		},
+	}
+
+	for _, test := range tests {
+		tokens, err := lex(test.input)
+		assert.Nil(t, err, test.input)
+		assert.Equal(t, len(test.values), len(tokens), test.input)
+		for i, tok := range tokens {
+			assert.Equal(t, test.values[i], tok.Value, test.input)
+			assert.Equal(t, test.kinds[i], tok.Kind, test.input)
+		}
+	}
+}
+
+func TestFoldSign(t *testing.T) {
+	tests := []struct {
+		sign   Token
+		num    Token
+		folded bool
+		value  string
+	}{
+		{
+			sign:   Token{Value: "-", Raw: "-", Kind: OperatorKind, Loc: Location{Col: 3}, Pos: 3, EndPos: 4},
+			num:    Token{Value: "5", Raw: "5", Kind: NumericKind, Loc: Location{Col: 4}, Pos: 4, EndPos: 5},
+			folded: true,
+			value:  "-5",
+		},
+		{
+			sign:   Token{Value: "+", Kind: OperatorKind},
+			num:    Token{Value: "3.2", Kind: NumericKind},
+			folded: true,
+			value:  "3.2",
+		},
+		{
+			sign:   Token{Value: "-", Kind: OperatorKind},
+			num:    Token{Value: "-5", Kind: NumericKind},
+			folded: true,
+			value:  "5",
+		},
+		// false tests
+		{
+			// - 5 中间有空白，折叠后 Raw 就不是源码中的文本了
+			sign: Token{Value: "-", Raw: "-", Kind: OperatorKind, Loc: Location{Col: 3}, Pos: 3, EndPos: 4},
+			num:  Token{Value: "5", Raw: "5", Kind: NumericKind, Loc: Location{Col: 5}, Pos: 5, EndPos: 6},
+		},
+		{
+			sign: Token{Value: "*", Kind: OperatorKind},
+			num:  Token{Value: "5", Kind: NumericKind},
+		},
+		{
+			sign: Token{Value: "-", Kind: OperatorKind},
+			num:  Token{Value: "a", Kind: IdentifierKind},
+		},
+	}
+
+	for _, test := range tests {
+		tok, ok := FoldSign(&test.sign, &test.num)
+		assert.Equal(t, test.folded, ok, test.sign.Value+test.num.Value)
+		if ok {
+			assert.Equal(t, test.value, tok.Value, test.sign.Value+test.num.Value)
+			assert.Equal(t, test.sign.Loc, tok.Loc, test.sign.Value+test.num.Value)
+			assert.Equal(t, test.sign.Raw+test.num.Raw, tok.Raw, test.sign.Value+test.num.Value)
+		}
+	}
+
+	// 语法分析器折叠出的 token 满足 Raw == source[Pos:EndPos]
+	for _, source := range []string{"select -5", "select - 5", "select +\n5", "select 1 - -2"} {
+		ast, err := Parse(source)
+		assert.Nil(t, err, source)
+		Walk(ast, func(n Node) bool {
+			if exp, ok := n.(*Expression); ok && exp.Kind == LiteralKind {
+				assert.Equal(t, source[exp.Literal.Pos:exp.Literal.EndPos], exp.Literal.Raw, source)
+			}
+			return true
+		})
+	}
+	ast, err := Parse("select - 5")
+	assert.Nil(t, err)
+	assert.Equal(t, UnaryKind, ast.Statements[0].SelectStatement.Item[0].Exp.Kind)
+}
+
+func TestLex_locations(t *testing.T) {
+	source := "select 1, 'multi\nline', a\nfrom t -- note\nwhere a = 1.5;"
+	tests := []struct {
+		value string
+		loc   Location
+	}{
+		{value: "select", loc: Location{Line: 0, Col: 0}},
+		{value: "1", loc: Location{Line: 0, Col: 7}},
+		{value: ",", loc: Location{Line: 0, Col: 8}},
+		{value: "multi\nline", loc: Location{Line: 0, Col: 10}},
+		{value: ",", loc: Location{Line: 1, Col: 5}},
+		{value: "a", loc: Location{Line: 1, Col: 7}},
+		{value: "from", loc: Location{Line: 2, Col: 0}},
+		{value: "t", loc: Location{Line: 2, Col: 5}},
+		{value: "where", loc: Location{Line: 3, Col: 0}},
+		{value: "a", loc: Location{Line: 3, Col: 6}},
+		{value: "=", loc: Location{Line: 3, Col: 8}},
+		{value: "1.5", loc: Location{Line: 3, Col: 10}},
+		{value: ";", loc: Location{Line: 3, Col: 13}},
+	}
+
+	tokens, err := lex(source)
+	assert.Nil(t, err)
+	assert.Equal(t, len(tests), len(tokens))
+
+	for i, test := range tests {
+		if i >= len(tokens) {
+			break
+		}
+		assert.Equal(t, test.value, tokens[i].Value, test.value)
+		assert.Equal(t, test.loc, tokens[i].Loc, test.value)
+	}
+}
+
+func TestLongestMatch(t *testing.T) {
+	tests := []struct {
+		source  string
+		options []string
+		match   string
+	}{
+		{
+			source:  "intoxicated",
+			options: []string{"int", "into"},
+			match:   "into",
+		},
+		{
+			source:  "asymmetric",
+			options: []string{"as", "a"},
+			match:   "as",
+		},
+		{
+			source:  "selectselectselect",
+			options: []string{"s", "select", "sel"},
+			match:   "select",
+		},
+		{
+			source:  "x",
+			options: []string{"xyz"},
+			match:   "",
+		},
+		{
+			source:  "ab",
+			options: []string{"abc", "a"},
+			match:   "a",
+		},
+	}
+
+	for _, test := range tests {
+		assert.NotPanics(t, func() {
+			assert.Equal(t, test.match, newMatcher(test.options, false).longestMatch(test.source, cursor{}), test.source)
+			assert.Equal(t, test.match, longestMatchReference(test.source, cursor{}, test.options), test.source)
+		}, test.source)
+	}
+}
+
+// longestMatchReference 是换成前缀树之前的 longestMatch，
+// 只用来和 matcher 做差分测试。
+func longestMatchReference(source string, ic cursor, options []string) string {
+	var value []byte
+	var skipList []int
+	var match string
+
+	cur := ic
+	for cur.pointer < uint(len(source)) {
+		value = append(value, strings.ToLower(string(source[cur.pointer]))...)
+		cur.pointer++
+
+	match:
+		for i, option := range options {
+			for _, skip := range skipList {
+				if i == skip {
+					continue match
+				}
+			}
+
+			if option == string(value) {
+				skipList = append(skipList, i)
+
+				if len(option) > len(match) {
+					match = option
+				}
+				continue
+			}
+
+			tooLong := len(value) > len(option)
+			if tooLong || string(value) != option[:len(value)] {
+				skipList = append(skipList, i)
+			}
+		}
+
+		if len(skipList) == len(options) {
+			break
+		}
+	}
+	return match
+}
+
+func TestMatcher_differential(t *testing.T) {
+	alphabet := "selctfromwhrinsvaudxyzSELECT_$19 ;,()*<>=!|-+/%'"
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 20000; i++ {
+		b := make([]byte, r.Intn(12))
+		for j := range b {
+			b[j] = alphabet[r.Intn(len(alphabet))]
+		}
+		source := string(b)
+
+		for p := uint(0); p < uint(len(source)); p++ {
+			ic := cursor{pointer: p}
+
+			want := longestMatchReference(source, ic, keywordOptions())
+			if continuesWord(source, p+uint(len(want))) {
+				want = ""
+			}
+			assert.Equal(t, want, keywordMatcher.longestMatch(source, ic), "%q at %d", source, p)
+
+			want = longestMatchReference(source, ic, symbolOptions())
+			assert.Equal(t, want, symbolMatcher.longestMatch(source, ic), "%q at %d", source, p)
+		}
+	}
+}
+
+func TestLex_randomASCII(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		b := make([]byte, r.Intn(32))
+		for j := range b {
+			b[j] = byte(r.Intn(128))
+		}
+		source := string(b)
+		assert.NotPanics(t, func() {
+			_, _ = lex(source)
+		}, "%q", source)
+	}
+}
+
+func TestLexers_atEnd(t *testing.T) {
+	lexers := []lexer{lexWhitespace, lexComment, lexKeyword, lexSymbol, lexString, lexEscapeString, lexDollarString, lexNumeric, lexParameter, lexNamedParameter, lexBool, lexIdentifier}
+	lexers = append(lexers, LexOptions{Dialect: PostgresDialect}.lexers()...)
+	lexers = append(lexers, LexOptions{Dialect: MySQLDialect}.lexers()...)
+
+	for _, source := range []string{"", "a", "select"} {
+		for _, ic := range []cursor{{pointer: uint(len(source))}, {pointer: uint(len(source)) + 1}} {
+			for _, l := range lexers {
+				assert.NotPanics(t, func() {
+					_, _, ok, err := l(source, ic)
+					assert.False(t, ok, "%q", source)
+					assert.Nil(t, err, "%q", source)
+				}, "%q", source)
+			}
+		}
+	}
+}
+
+func FuzzLex(f *testing.F) {
+	for _, seed := range []string{
+		"select a, b from t where a >= 1;",
+		"insert into t values ('it''s', 1.5e3, $1, :name, ?);",
+		"select E'\\x41', $tag$x$tag$ -- c",
+		"/* block */ select 0x1F::int",
+		"'",
+		"$",
+		"0x",
+		"1e",
+		"1_",
+		":",
+		"|",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, source string) {
+		tokens, err := lex(source)
+		if err == nil && tokens == nil {
+			t.Fatalf("no tokens and no error for %q", source)
+		}
+		if err != nil && tokens != nil {
+			t.Fatalf("both tokens and error for %q", source)
+		}
+	})
+}
+
+func TestLex_whitespace(t *testing.T) {
+	tests := []struct {
+		input string
+		locs  []Location
+	}{
+		{
+			input: "select a\r\nfrom b",
+			locs:  []Location{{Line: 0, Col: 0}, {Line: 0, Col: 7}, {Line: 1, Col: 0}, {Line: 1, Col: 5}},
+		},
+		{
+			input: "select\ta\f\vfrom\r\n\r\nb",
+			locs:  []Location{{Line: 0, Col: 0}, {Line: 0, Col: 7}, {Line: 0, Col: 10}, {Line: 2, Col: 0}},
+		},
+		{
+			input: "select\n\na\nfrom b",
+			locs:  []Location{{Line: 0, Col: 0}, {Line: 2, Col: 0}, {Line: 3, Col: 0}, {Line: 3, Col: 5}},
+		},
+	}
+
+	for _, test := range tests {
+		tokens, err := lex(test.input)
+		assert.Nil(t, err, test.input)
+		assert.Equal(t, len(test.locs), len(tokens), test.input)
+		for i, tok := range tokens {
+			assert.Equal(t, test.locs[i], tok.Loc, "%q %s", test.input, tok.Value)
+		}
+	}
+}
+
+func TestLex_span(t *testing.T) {
+	source := "select 'it''s', \"Id\", 1.5e3, 0x1F, a <= $1, :name, true -- c\nfrom 'a\nb'||x;"
+	raw := []string{"select", "'it''s'", ",", `"Id"`, ",", "1.5e3", ",", "0x1F", ",", "a", "<=", "$1", ",", ":name", ",", "true", "from", "'a\nb'", "||", "x", ";"}
+
+	tokens, err := lex(source)
+	assert.Nil(t, err)
+	assert.Equal(t, len(raw), len(tokens))
+
+	for i, tok := range tokens {
+		assert.Equal(t, raw[i], source[tok.Pos:tok.EndPos], raw[i])
+		assert.Equal(t, raw[i], tok.Raw, raw[i])
+		assert.Equal(t, tok.Loc, tok.Start(), raw[i])
+	}
+
+	str := tokens[17]
+	assert.Equal(t, Location{Line: 1, Col: 5}, str.Loc)
+	assert.Equal(t, Location{Line: 2, Col: 2}, str.End)
+	assert.Equal(t, Location{Line: 2, Col: 4}, tokens[18].End)
+}
+
+func TestLexWithTrivia(t *testing.T) {
+	tests := []struct {
+		input string
+		kinds []TokenKind
+	}{
+		{
+			input: "select a",
+			kinds: []TokenKind{KeywordKind, WhitespaceKind, IdentifierKind},
 		},
 		{
-			input: "select true",
-			Tokens: []Token{
-				{
-					Loc:   Location{Col: 0, Line: 0},
-					Value: string(SelectKeyword),
-					Kind:  KeywordKind,
-				},
-				{
-					Loc:   Location{Col: 7, Line: 0},
-					Value: "true",
-					Kind:  BoolKind,
-				},
+			input: "SELECT  'it''s' -- note\r\nFROM \"T\" /* a\n b */;\n",
+			kinds: []TokenKind{
+				KeywordKind, WhitespaceKind, StringKind, WhitespaceKind, CommentKind, WhitespaceKind,
+				KeywordKind, WhitespaceKind, IdentifierKind, WhitespaceKind, CommentKind, SymbolKind, WhitespaceKind,
 			},
 		},
 		{
-			input: "select 1",
-			Tokens: []Token{
-				{
-					Loc:   Location{Col: 0, Line: 0},
-					Value: string(SelectKeyword),
-					Kind:  KeywordKind,
-				},
-				{
-					Loc:   Location{Col: 7, Line: 0},
-					Value: "1",
-					Kind:  NumericKind,
-				},
-			},
-			err: nil,
+			input: " \t\n",
+			kinds: []TokenKind{WhitespaceKind},
 		},
+	}
+
+	for _, test := range tests {
+		tokens, err := LexWithTrivia(test.input)
+		assert.Nil(t, err, test.input)
+
+		var b strings.Builder
+		var kinds []TokenKind
+		for _, tok := range tokens {
+			b.WriteString(tok.Raw)
+			kinds = append(kinds, tok.Kind)
+		}
+		assert.Equal(t, test.input, b.String(), test.input)
+		assert.Equal(t, test.kinds, kinds, test.input)
+
+		// 默认模式仍然丢弃空白和注释
+		tokens, err = lex(test.input)
+		assert.Nil(t, err, test.input)
+		for _, tok := range tokens {
+			assert.False(t, tok.isTrivia(), test.input)
+		}
+	}
+}
+
+func TestLex_raw(t *testing.T) {
+	tokens, err := lex(`SELECT UserName, "UserName", 'It''s', TRUE FROM Users`)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		value string
+		raw   string
+	}{
+		{value: "select", raw: "SELECT"},
+		{value: "username", raw: "UserName"},
+		{value: ",", raw: ","},
+		{value: "UserName", raw: `"UserName"`},
+		{value: ",", raw: ","},
+		{value: "It's", raw: "'It''s'"},
+		{value: ",", raw: ","},
+		{value: "true", raw: "TRUE"},
+		{value: "from", raw: "FROM"},
+		{value: "users", raw: "Users"},
+	}
+	assert.Equal(t, len(tests), len(tokens))
+	for i, test := range tests {
+		assert.Equal(t, test.value, tokens[i].Value, test.raw)
+		assert.Equal(t, test.raw, tokens[i].Raw, test.raw)
+	}
+
+	// equals 只比较规范化后的值
+	assert.True(t, tokens[0].equals(&Token{Value: "select", Raw: "select", Kind: KeywordKind}))
+}
+
+func TestLexWithOptions(t *testing.T) {
+	type token struct {
+		value string
+		kind  TokenKind
+	}
+	tests := []struct {
+		input   string
+		options LexOptions
+		tokens  []token
+		err     string
+	}{
 		{
-			input: "select 'foo' || 'bar';",
-			Tokens: []Token{
-				{
-					Loc:   Location{Col: 0, Line: 0},
-					Value: string(SelectKeyword),
-					Kind:  KeywordKind,
-				},
-				{
-					Loc:   Location{Col: 7, Line: 0},
-					Value: "foo",
-					Kind:  StringKind,
-				},
-				{
-					Loc:   Location{Col: 13, Line: 0},
-					Value: string(ConcatSymbol),
-					Kind:  SymbolKind,
-				},
-				{
-					Loc:   Location{Col: 16, Line: 0},
-					Value: "bar",
-					Kind:  StringKind,
-				},
-				{
-					Loc:   Location{Col: 21, Line: 0},
-					Value: string(SemicolonSymbol),
-					Kind:  SymbolKind,
-				},
-			},
-			err: nil,
+			input:   `select "Name" from t`,
+			options: LexOptions{Dialect: PostgresDialect},
+			tokens:  []token{{"select", KeywordKind}, {"Name", IdentifierKind}, {"from", KeywordKind}, {"t", IdentifierKind}},
 		},
 		{
-			input: "CREATE TABLE u (id INT, name TEXT)",
-			Tokens: []Token{
-				{
-					Loc:   Location{Col: 0, Line: 0},
-					Value: string(CreateKeyword),
-					Kind:  KeywordKind,
-				},
-				{
-					Loc:   Location{Col: 7, Line: 0},
-					Value: string(TableKeyword),
-					Kind:  KeywordKind,
-				},
-				{
-					Loc:   Location{Col: 13, Line: 0},
-					Value: "u",
-					Kind:  IdentifierKind,
-				},
-				{
-					Loc:   Location{Col: 15, Line: 0},
-					Value: "(",
-					Kind:  SymbolKind,
-				},
-				{
-					Loc:   Location{Col: 16, Line: 0},
-					Value: "id",
-					Kind:  IdentifierKind,
-				},
-				{
-					Loc:   Location{Col: 19, Line: 0},
-					Value: "int",
-					Kind:  KeywordKind,
-				},
-				{
-					Loc:   Location{Col: 22, Line: 0},
-					Value: ",",
-					Kind:  SymbolKind,
-				},
-				{
-					Loc:   Location{Col: 24, Line: 0},
-					Value: "name",
-					Kind:  IdentifierKind,
-				},
-				{
-					Loc:   Location{Col: 29, Line: 0},
-					Value: "text",
-					Kind:  KeywordKind,
-				},
-				{
-					Loc:   Location{Col: 33, Line: 0},
-					Value: ")",
-					Kind:  SymbolKind,
-				},
+			input:   `select "Name" from t`,
+			options: LexOptions{Dialect: MySQLDialect},
+			tokens:  []token{{"select", KeywordKind}, {"Name", StringKind}, {"from", KeywordKind}, {"t", IdentifierKind}},
+		},
+		{
+			input:   "where a = $1",
+			options: LexOptions{Dialect: PostgresDialect},
+			tokens:  []token{{"where", KeywordKind}, {"a", IdentifierKind}, {"=", OperatorKind}, {"$1", ParameterKind}},
+		},
+		{
+			input:   "where a = $1",
+			options: LexOptions{Dialect: MySQLDialect},
+			err:     "Unable to lex token after operator '=' at 1:11",
+		},
+		{
+			input:   "where a = ?",
+			options: LexOptions{Dialect: MySQLDialect},
+			tokens:  []token{{"where", KeywordKind}, {"a", IdentifierKind}, {"=", OperatorKind}, {"?", ParameterKind}},
+		},
+		{
+			input:   "where a = ?",
+			options: LexOptions{Dialect: PostgresDialect},
+			err:     "Unable to lex token after operator '=' at 1:11",
+		},
+		{
+			input:   "insert into t values (1) RETURNING id",
+			options: LexOptions{ExtraKeywords: []Keyword{"returning"}},
+			tokens: []token{
+				{"insert", KeywordKind}, {"into", KeywordKind}, {"t", IdentifierKind}, {"values", KeywordKind},
+				{"(", SymbolKind}, {"1", NumericKind}, {")", SymbolKind}, {"returning", KeywordKind}, {"id", IdentifierKind},
 			},
 		},
 		{
-			input: "insert into users Values (105, 233)",
-			Tokens: []Token{
-				{
-					Loc:   Location{Col: 0, Line: 0},
-					Value: string(InsertKeyword),
-					Kind:  KeywordKind,
-				},
-				{
-					Loc:   Location{Col: 7, Line: 0},
-					Value: string(IntoKeyword),
-					Kind:  KeywordKind,
-				},
-				{
-					Loc:   Location{Col: 12, Line: 0},
-					Value: "users",
-					Kind:  IdentifierKind,
-				},
-				{
-					Loc:   Location{Col: 18, Line: 0},
-					Value: string(ValuesKeyword),
-					Kind:  KeywordKind,
-				},
-				{
-					Loc:   Location{Col: 25, Line: 0},
-					Value: "(",
-					Kind:  SymbolKind,
-				},
-				{
-					Loc:   Location{Col: 26, Line: 0},
-					Value: "105",
-					Kind:  NumericKind,
-				},
-				{
-					Loc:   Location{Col: 30, Line: 0},
-					Value: ",",
-					Kind:  SymbolKind,
-				},
-				{
-					Loc:   Location{Col: 32, Line: 0},
-					Value: "233",
-					Kind:  NumericKind,
-				},
-				{
-					Loc:   Location{Col: 36, Line: 0},
-					Value: ")",
-					Kind:  SymbolKind,
-				},
+			input:   "select returning",
+			options: LexOptions{},
+			tokens:  []token{{"select", KeywordKind}, {"returning", IdentifierKind}},
+		},
+		{
+			input:   "select `Order Id`, `a``b` from `t`",
+			options: LexOptions{AllowBacktickIdentifiers: true},
+			tokens: []token{
+				{"select", KeywordKind}, {"Order Id", IdentifierKind}, {",", SymbolKind},
+				{"a`b", IdentifierKind}, {"from", KeywordKind}, {"t", IdentifierKind},
 			},
-			err: nil,
 		},
 		{
-			input: "SELECT id FROM users;",
-			Tokens: []Token{
-				{
-					Loc:   Location{Col: 0, Line: 0},
-					Value: string(SelectKeyword),
-					Kind:  KeywordKind,
-				},
-				{
-					Loc:   Location{Col: 7, Line: 0},
-					Value: "id",
-					Kind:  IdentifierKind,
-				},
-				{
-					Loc:   Location{Col: 10, Line: 0},
-					Value: string(FromKeyword),
-					Kind:  KeywordKind,
-				},
-				{
-					Loc:   Location{Col: 15, Line: 0},
-					Value: "users",
-					Kind:  IdentifierKind,
-				},
-				{
-					Loc:   Location{Col: 20, Line: 0},
-					Value: ";",
-					Kind:  SymbolKind,
-				},
+			input:   "select `id` from t",
+			options: LexOptions{Dialect: MySQLDialect},
+			tokens:  []token{{"select", KeywordKind}, {"id", IdentifierKind}, {"from", KeywordKind}, {"t", IdentifierKind}},
+		},
+		{
+			input:   "select `id from t",
+			options: LexOptions{Dialect: MySQLDialect},
+			err:     "unterminated quoted identifier starting at line 1 col 8",
+		},
+		{
+			input:   "select `id` from t",
+			options: LexOptions{},
+			err:     "Unable to lex token after keyword 'select' at 1:8",
+		},
+		{
+			input:   "select $$a$$, $1",
+			options: LexOptions{},
+			tokens:  []token{{"select", KeywordKind}, {"a", StringKind}, {",", SymbolKind}, {"$1", ParameterKind}},
+		},
+		{
+			input:   "select $$a$$",
+			options: LexOptions{Dialect: MySQLDialect},
+			err:     "Unable to lex token after keyword 'select' at 1:8",
+		},
+		{
+			input:   "select '5'::int, a::text, 1::int, :p::int",
+			options: LexOptions{},
+			tokens: []token{
+				{"select", KeywordKind}, {"5", StringKind}, {"::", OperatorKind}, {"int", KeywordKind}, {",", SymbolKind},
+				{"a", IdentifierKind}, {"::", OperatorKind}, {"text", KeywordKind}, {",", SymbolKind},
+				{"1", NumericKind}, {"::", OperatorKind}, {"int", KeywordKind}, {",", SymbolKind},
+				{"p", NamedParameterKind}, {"::", OperatorKind}, {"int", KeywordKind},
 			},
-			err: nil,
+		},
+		{
+			input:   "select a :::b",
+			options: LexOptions{},
+			tokens:  []token{{"select", KeywordKind}, {"a", IdentifierKind}, {"::", OperatorKind}, {"b", NamedParameterKind}},
+		},
+		{
+			input:   "select a: int",
+			options: LexOptions{},
+			err:     "expected parameter name after ':' at line 1 col 9",
+		},
+		{
+			input:   "select E'a\\n', e",
+			options: LexOptions{},
+			tokens:  []token{{"select", KeywordKind}, {"a\n", StringKind}, {",", SymbolKind}, {"e", IdentifierKind}},
+		},
+		{
+			input:   "select\n  E'\\z'",
+			options: LexOptions{},
+			err:     `invalid escape sequence \z at line 2 col 5`,
 		},
 	}
 
 	for _, test := range tests {
-		tokens, err := lex(test.input)
-		assert.Equal(t, test.err, err, test.input)
-		assert.Equal(t, len(test.Tokens), len(tokens), test.input)
+		tokens, err := LexWithOptions(test.input, test.options)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+		assert.Nil(t, err, test.input)
 
-		for i, tok := range tokens {
-			assert.Equal(t, &test.Tokens[i], tok, test.input)
+		var got []token
+		for _, tok := range tokens {
+			got = append(got, token{tok.Value, tok.Kind})
 		}
+		assert.Equal(t, test.tokens, got, test.input)
+	}
+}
+
+func TestLexWithOptions_zeroValue(t *testing.T) {
+	inputs := []string{
+		`select "Name", 'a' || 'b', $1, ?, :id from t where a <= 1.5e3; -- done`,
+		"insert into users values (0x1F, true, null)",
+	}
+
+	for _, input := range inputs {
+		want, wantErr := lex(input)
+		got, err := LexWithOptions(input, LexOptions{})
+		assert.Equal(t, wantErr, err, input)
+		assert.Equal(t, want, got, input)
+	}
+}
+
+func TestLex_unicodeIdentifiers(t *testing.T) {
+	source := "select 名前, café from ユーザー where 'ü' = naïve"
+	tests := []struct {
+		value string
+		kind  TokenKind
+		col   uint
+	}{
+		{value: "select", kind: KeywordKind, col: 0},
+		{value: "名前", kind: IdentifierKind, col: 7},
+		{value: ",", kind: SymbolKind, col: 9},
+		{value: "café", kind: IdentifierKind, col: 11},
+		{value: "from", kind: KeywordKind, col: 16},
+		{value: "ユーザー", kind: IdentifierKind, col: 21},
+		{value: "where", kind: KeywordKind, col: 26},
+		{value: "ü", kind: StringKind, col: 32},
+		{value: "=", kind: OperatorKind, col: 36},
+		{value: "naïve", kind: IdentifierKind, col: 38},
+	}
+
+	tokens, err := lex(source)
+	assert.Nil(t, err)
+	assert.Equal(t, len(tests), len(tokens))
+	for i, test := range tests {
+		assert.Equal(t, test.value, tokens[i].Value, test.value)
+		assert.Equal(t, test.kind, tokens[i].Kind, test.value)
+		assert.Equal(t, Location{Col: test.col}, tokens[i].Loc, test.value)
+		assert.Equal(t, tokens[i].Raw, source[tokens[i].Pos:tokens[i].EndPos], test.value)
 	}
 }
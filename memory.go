@@ -0,0 +1,340 @@
+package gosql
+
+import "strings"
+
+// MemoryBackend 把表保存在内存里，表名不区分大小写。
+// 每张表是一组按列定义顺序排列的行。
+type MemoryBackend struct {
+	tables map[string]*memoryTable
+}
+
+type memoryTable struct {
+	name    string
+	columns []ResultColumn
+	rows    [][]Cell
+}
+
+// NewMemoryBackend 返回一个没有任何表的 MemoryBackend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{tables: map[string]*memoryTable{}}
+}
+
+// 列定义中的类型关键字对应的列类型，不在表里的类型还不支持
+var datatypeColumnTypes = map[Keyword]ColumnType{
+	IntKeyword:     IntType,
+	BigintKeyword:  IntType,
+	TextKeyword:    TextType,
+	VarcharKeyword: TextType,
+	CharKeyword:    TextType,
+	BooleanKeyword: BoolType,
+}
+
+func (mb *MemoryBackend) CreateTable(crt *CreateTableStatement) error {
+	key := strings.ToLower(crt.Name.Value)
+	if _, ok := mb.tables[key]; ok {
+		if crt.IfNotExists {
+			return nil
+		}
+		return execErrorAt(crt.Name.Loc, "table %s already exists", crt.Name.Value)
+	}
+
+	t := memoryTable{name: crt.Name.Value}
+	for _, col := range crt.Cols {
+		if t.column(col.Name.Value) >= 0 {
+			return execErrorAt(col.Name.Loc, "column %s specified more than once", col.Name.Value)
+		}
+		typ, ok := datatypeColumnTypes[Keyword(col.Datatype.Value)]
+		if !ok {
+			return execErrorAt(col.Datatype.Loc, "column type %s is not supported", col.Datatype.Value)
+		}
+		t.columns = append(t.columns, ResultColumn{Name: col.Name.Value, Type: typ})
+	}
+
+	mb.tables[key] = &t
+	return nil
+}
+
+func (mb *MemoryBackend) table(name *Token) (*memoryTable, error) {
+	t, ok := mb.tables[strings.ToLower(name.Value)]
+	if !ok {
+		return nil, execErrorAt(name.Loc, "table %s does not exist", name.Value)
+	}
+	return t, nil
+}
+
+// column 返回名为 name 的列的位置，没有这一列时返回 -1
+func (t *memoryTable) column(name string) int {
+	for i, col := range t.columns {
+		if col.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Insert 只支持 values，每个值都必须是和所在列类型相同的字面量。
+// 有列名列表时必须把表的每一列列出恰好一次，顺序可以和表不同。
+func (mb *MemoryBackend) Insert(inst *InsertStatement) error {
+	t, err := mb.table(&inst.Table)
+	if err != nil {
+		return err
+	}
+	if inst.Select != nil {
+		return execErrorAt(inst.Select.Start, "insert into %s with SELECT is not supported", t.name)
+	}
+
+	// positions[i] 是第 i 个值在行中的位置
+	positions := make([]int, len(t.columns))
+	for i := range positions {
+		positions[i] = i
+	}
+	if inst.Cols != nil {
+		positions = positions[:0]
+		seen := make([]bool, len(t.columns))
+		for _, col := range inst.Cols {
+			i := t.column(col.Value)
+			if i < 0 {
+				return execErrorAt(col.Loc, "column %s does not exist in table %s", col.Value, t.name)
+			}
+			if seen[i] {
+				return execErrorAt(col.Loc, "column %s specified more than once", col.Value)
+			}
+			seen[i] = true
+			positions = append(positions, i)
+		}
+		if len(positions) < len(t.columns) {
+			return execErrorAt(inst.Table.Loc, "insert into %s must give a value for every column", t.name)
+		}
+	}
+
+	rows := make([][]Cell, 0, len(inst.Values))
+	for _, values := range inst.Values {
+		if len(values) != len(positions) {
+			return execErrorAt(values[0].Start, "insert into %s has %d columns but %d values", t.name, len(positions), len(values))
+		}
+
+		row := make([]Cell, len(t.columns))
+		for i, exp := range values {
+			if exp.Kind != LiteralKind {
+				return execErrorAt(exp.Start, "only literals can be inserted")
+			}
+			cell, typ, err := LiteralCell(exp.Literal)
+			if err != nil {
+				return err
+			}
+			col := t.columns[positions[i]]
+			if typ != col.Type {
+				return execErrorAt(exp.Start, "value %s is not valid for column %s of type %s", literalString(exp.Literal), col.Name, col.Type)
+			}
+			row[positions[i]] = cell
+		}
+		rows = append(rows, row)
+	}
+
+	// 所有行都检查过之后再插入，出错时表保持不变
+	t.rows = append(t.rows, rows...)
+	return nil
+}
+
+// projection 从表中的一行求出结果中的一列
+type projection func(row []Cell) Cell
+
+// Select 支持从一张表（或者没有 from）中选出满足 where 的行，
+// 其他子句还不支持。
+func (mb *MemoryBackend) Select(slct *SelectStatement) (*Results, error) {
+	if err := checkSelectSupported(slct); err != nil {
+		return nil, err
+	}
+
+	var t *memoryTable
+	if slct.From != nil {
+		if slct.From.Name == nil {
+			return nil, execErrorAt(slct.Start, "subqueries in FROM are not supported")
+		}
+		var err error
+		t, err = mb.table(slct.From.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var where projection
+	if slct.Where != nil {
+		col, p, err := t.project(slct.From, slct.Where)
+		if err != nil {
+			return nil, err
+		}
+		if col.Type != BoolType {
+			return nil, execErrorAt(slct.Where.Start, "argument of WHERE must be type bool, not type %s", col.Type)
+		}
+		where = p
+	}
+
+	results := Results{}
+	var projections []projection
+	for _, item := range slct.Item {
+		if item.Asterisk {
+			if t == nil {
+				return nil, execErrorAt(slct.Start, "SELECT * with no tables specified is not valid")
+			}
+			for i, col := range t.columns {
+				i := i
+				results.Columns = append(results.Columns, col)
+				projections = append(projections, func(row []Cell) Cell { return row[i] })
+			}
+			continue
+		}
+
+		col, p, err := t.project(slct.From, item.Exp)
+		if err != nil {
+			return nil, err
+		}
+		if item.As != nil {
+			col.Name = item.As.Value
+		}
+		results.Columns = append(results.Columns, col)
+		projections = append(projections, p)
+	}
+
+	// 没有 from 时在一行不含任何列的空行上求值
+	rows := [][]Cell{{}}
+	if t != nil {
+		rows = t.rows
+	}
+	for _, row := range rows {
+		if where != nil {
+			if ok, _ := where(row).AsBool(); !ok {
+				continue
+			}
+		}
+		result := make([]Cell, len(projections))
+		for i, p := range projections {
+			result[i] = p(row)
+		}
+		results.Rows = append(results.Rows, result)
+	}
+	return &results, nil
+}
+
+func checkSelectSupported(slct *SelectStatement) error {
+	switch {
+	case slct.Distinct:
+		return execErrorAt(slct.Start, "SELECT DISTINCT is not supported")
+	case slct.Joins != nil:
+		return execErrorAt(slct.Joins[0].On.Start, "JOIN is not supported")
+	case slct.GroupBy != nil:
+		return execErrorAt(slct.GroupBy[0].Start, "GROUP BY is not supported")
+	case slct.Having != nil:
+		return execErrorAt(slct.Having.Start, "HAVING is not supported")
+	case slct.OrderBy != nil:
+		return execErrorAt(slct.OrderBy[0].Exp.Start, "ORDER BY is not supported")
+	case slct.Limit != nil:
+		return execErrorAt(slct.Limit.Start, "LIMIT is not supported")
+	case slct.Offset != nil:
+		return execErrorAt(slct.Offset.Start, "OFFSET is not supported")
+	}
+	return nil
+}
+
+// project 返回表达式 exp 在结果中的列和求值函数。exp 可以是列名、字面量，
+// 以及由它们组成的比较和 and、or、not，t 为 nil 时没有列。
+// 类型在这里就检查完，求值函数不会出错。
+func (t *memoryTable) project(from *TableReference, exp *Expression) (ResultColumn, projection, error) {
+	var name *Token
+	switch {
+	case exp.Kind == ColumnKind:
+		if q := exp.Column.Qualifier; q != nil {
+			if from == nil || (from.Alias == nil && q.Value != from.Name.Value) ||
+				(from.Alias != nil && q.Value != from.Alias.Value) {
+				return ResultColumn{}, nil, execErrorAt(exp.Start, "table %s is not in FROM", q.Value)
+			}
+		}
+		name = &exp.Column.Name
+	case exp.Kind == LiteralKind:
+		cell, typ, err := LiteralCell(exp.Literal)
+		if err != nil {
+			return ResultColumn{}, nil, err
+		}
+		return ResultColumn{Name: "?column?", Type: typ}, func([]Cell) Cell { return cell }, nil
+	case exp.Kind == BinaryKind:
+		return t.projectBinary(from, exp)
+	case exp.Kind == UnaryKind && exp.Unary.Op.Kind == KeywordKind && exp.Unary.Op.Value == string(NotKeyword):
+		col, p, err := t.project(from, exp.Unary.Operand)
+		if err != nil {
+			return ResultColumn{}, nil, err
+		}
+		if col.Type != BoolType {
+			return ResultColumn{}, nil, execErrorAt(exp.Unary.Operand.Start, "argument of NOT must be type bool, not type %s", col.Type)
+		}
+		return ResultColumn{Name: "?column?", Type: BoolType}, func(row []Cell) Cell {
+			v, _ := p(row).AsBool()
+			return boolCell(!v)
+		}, nil
+	default:
+		return ResultColumn{}, nil, execErrorAt(exp.Start, "expression %s is not supported", exp)
+	}
+
+	if t == nil {
+		return ResultColumn{}, nil, execErrorAt(name.Loc, "column %s does not exist", name.Value)
+	}
+	i := t.column(name.Value)
+	if i < 0 {
+		return ResultColumn{}, nil, execErrorAt(name.Loc, "column %s does not exist in table %s", name.Value, t.name)
+	}
+	return t.columns[i], func(row []Cell) Cell { return row[i] }, nil
+}
+
+// 比较运算符对 compareCells 的结果的判断
+var comparisons = map[Symbol]func(c int) bool{
+	EqSymbol:  func(c int) bool { return c == 0 },
+	NeqSymbol: func(c int) bool { return c != 0 },
+	LtSymbol:  func(c int) bool { return c < 0 },
+	GtSymbol:  func(c int) bool { return c > 0 },
+	LteSymbol: func(c int) bool { return c <= 0 },
+	GteSymbol: func(c int) bool { return c >= 0 },
+}
+
+// projectBinary 处理 and、or 和比较。and、or 的两边都必须是 bool，
+// 右边只在需要时求值；比较的两边必须是同一种类型。
+func (t *memoryTable) projectBinary(from *TableReference, exp *Expression) (ResultColumn, projection, error) {
+	bin := exp.Binary
+	logical := bin.Op.Kind == KeywordKind && (bin.Op.Value == string(AndKeyword) || bin.Op.Value == string(OrKeyword))
+	compare, ok := comparisons[Symbol(bin.Op.Value)]
+	if !logical && (bin.Op.Kind == KeywordKind || !ok) {
+		return ResultColumn{}, nil, execErrorAt(exp.Start, "expression %s is not supported", exp)
+	}
+
+	a, pa, err := t.project(from, bin.A)
+	if err != nil {
+		return ResultColumn{}, nil, err
+	}
+	b, pb, err := t.project(from, bin.B)
+	if err != nil {
+		return ResultColumn{}, nil, err
+	}
+	result := ResultColumn{Name: "?column?", Type: BoolType}
+
+	if !logical {
+		if a.Type != b.Type {
+			return ResultColumn{}, nil, execErrorAt(bin.Op.Loc, "operator %s is not defined for %s and %s", bin.Op.Value, a.Type, b.Type)
+		}
+		return result, func(row []Cell) Cell {
+			return boolCell(compare(compareCells(pa(row), pb(row))))
+		}, nil
+	}
+
+	if a.Type != BoolType {
+		return ResultColumn{}, nil, execErrorAt(bin.A.Start, "argument of %s must be type bool, not type %s", strings.ToUpper(bin.Op.Value), a.Type)
+	}
+	if b.Type != BoolType {
+		return ResultColumn{}, nil, execErrorAt(bin.B.Start, "argument of %s must be type bool, not type %s", strings.ToUpper(bin.Op.Value), b.Type)
+	}
+	// and 在左边为 false 时、or 在左边为 true 时不再对右边求值
+	stop := bin.Op.Value == string(OrKeyword)
+	return result, func(row []Cell) Cell {
+		if v, _ := pa(row).AsBool(); v == stop {
+			return boolCell(stop)
+		}
+		return pb(row)
+	}, nil
+}
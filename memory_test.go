@@ -0,0 +1,284 @@
+package gosql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// execute 依次执行 source 中的语句，返回最后一个 select 的结果
+func execute(mb *MemoryBackend, source string) (*Results, error) {
+	ast, err := Parse(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var results *Results
+	for _, stmt := range ast.Statements {
+		switch stmt.Kind {
+		case CreateTableKind:
+			err = mb.CreateTable(stmt.CreateTableStatement)
+		case InsertKind:
+			err = mb.Insert(stmt.InsertStatement)
+		case SelectKind:
+			results, err = mb.Select(stmt.SelectStatement)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func TestMemoryBackend(t *testing.T) {
+	var backend Backend = NewMemoryBackend()
+	mb := backend.(*MemoryBackend)
+
+	results, err := execute(mb, `create table users (id int, name text);
+insert into users values (1, 'Ada');
+insert into USERS (name, id) values ('Grace', 2);
+select * from users`)
+	assert.Nil(t, err)
+	assert.Equal(t, &Results{
+		Columns: []ResultColumn{{Name: "id", Type: IntType}, {Name: "name", Type: TextType}},
+		Rows:    [][]Cell{{intCell(1), textCell("Ada")}, {intCell(2), textCell("Grace")}},
+	}, results)
+
+	results, err = execute(mb, "select name, u.id as ident, 'x', 42, true from users u")
+	assert.Nil(t, err)
+	assert.Equal(t, &Results{
+		Columns: []ResultColumn{
+			{Name: "name", Type: TextType},
+			{Name: "ident", Type: IntType},
+			{Name: "?column?", Type: TextType},
+			{Name: "?column?", Type: IntType},
+			{Name: "?column?", Type: BoolType},
+		},
+		Rows: [][]Cell{
+			{textCell("Ada"), intCell(1), textCell("x"), intCell(42), boolCell(true)},
+			{textCell("Grace"), intCell(2), textCell("x"), intCell(42), boolCell(true)},
+		},
+	}, results)
+
+	results, err = execute(mb, "select 1, 'one'")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]Cell{{intCell(1), textCell("one")}}, results.Rows)
+
+	// 调用方直接读出值，不需要解析字符串
+	results, err = execute(mb, "create table flags (n bigint, name varchar(10), active boolean); insert into flags values (-0x10, '', false); select * from flags")
+	assert.Nil(t, err)
+	assert.Equal(t, []ResultColumn{{Name: "n", Type: IntType}, {Name: "name", Type: TextType}, {Name: "active", Type: BoolType}}, results.Columns)
+	n, ok := results.Rows[0][0].AsInt()
+	assert.True(t, ok)
+	assert.Equal(t, int64(-16), n)
+	name, ok := results.Rows[0][1].AsText()
+	assert.True(t, ok)
+	assert.Equal(t, "", name)
+	active, ok := results.Rows[0][2].AsBool()
+	assert.True(t, ok)
+	assert.False(t, active)
+
+	results, err = execute(mb, "create table empty (a bigint, b varchar(10)); select * from empty")
+	assert.Nil(t, err)
+	assert.Equal(t, []ResultColumn{{Name: "a", Type: IntType}, {Name: "b", Type: TextType}}, results.Columns)
+	assert.Nil(t, results.Rows)
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "create table users (id int)",
+			err:    "table users already exists at line 1 col 14",
+		},
+		{
+			source: "create table t (ok real)",
+			err:    "column type real is not supported at line 1 col 20",
+		},
+		{
+			source: "insert into nope values (1)",
+			err:    "table nope does not exist at line 1 col 13",
+		},
+		{
+			source: "insert into users (id, nick) values (1, 'x')",
+			err:    "column nick does not exist in table users at line 1 col 24",
+		},
+		{
+			source: "create table dup (id int, name text, id text)",
+			err:    "column id specified more than once at line 1 col 38",
+		},
+		{
+			source: "insert into users (id, id) values (1, 2)",
+			err:    "column id specified more than once at line 1 col 24",
+		},
+		{
+			source: "insert into users (name, id, name) values ('x', 3, 'y')",
+			err:    "column name specified more than once at line 1 col 30",
+		},
+		{
+			source: "insert into users (id) values (1)",
+			err:    "insert into users must give a value for every column at line 1 col 13",
+		},
+		{
+			source: "insert into users values (3)",
+			err:    "insert into users has 2 columns but 1 values at line 1 col 27",
+		},
+		{
+			source: "insert into users values ('oops', 'Ada')",
+			err:    "value 'oops' is not valid for column id of type int at line 1 col 27",
+		},
+		{
+			source: "insert into users values (3, 42)",
+			err:    "value 42 is not valid for column name of type text at line 1 col 30",
+		},
+		{
+			source: "insert into users (name, id) values ('Edsger', '3')",
+			err:    "value '3' is not valid for column id of type int at line 1 col 48",
+		},
+		{
+			source: "insert into users values (3, true)",
+			err:    "value true is not valid for column name of type text at line 1 col 30",
+		},
+		{
+			source: "insert into users values (3, 'Edsger'), (4, 5)",
+			err:    "value 5 is not valid for column name of type text at line 1 col 45",
+		},
+		{
+			source: "insert into users values (1 + 1, 'x')",
+			err:    "only literals can be inserted at line 1 col 27",
+		},
+		{
+			source: "insert into users values (99999999999999999999, 'x')",
+			err:    "numeric literal 99999999999999999999 is out of range for type int at line 1 col 27",
+		},
+		{
+			source: "select id, $1 from users",
+			err:    "parameter $1 is not a supported literal value at line 1 col 12",
+		},
+		{
+			source: "insert into users select * from users",
+			err:    "insert into users with SELECT is not supported at line 1 col 19",
+		},
+		{
+			source: "select nick from users",
+			err:    "column nick does not exist in table users at line 1 col 8",
+		},
+		{
+			source: "select v.id from users",
+			err:    "table v is not in FROM at line 1 col 8",
+		},
+		{
+			source: "select upper(name) from users",
+			err:    "expression upper(name) is not supported at line 1 col 8",
+		},
+		{
+			source: "select *",
+			err:    "SELECT * with no tables specified is not valid at line 1 col 1",
+		},
+		{
+			source: "select id",
+			err:    "column id does not exist at line 1 col 8",
+		},
+	}
+	for _, test := range errors {
+		_, err := execute(mb, test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+
+	// 出错的 insert 不会插入任何一行
+	results, err = execute(mb, "select id from users")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]Cell{{intCell(1)}, {intCell(2)}}, results.Rows)
+}
+
+func TestMemoryBackend_where(t *testing.T) {
+	mb := NewMemoryBackend()
+	_, err := execute(mb, `create table users (id int, name text, admin boolean);
+insert into users values (1, 'Ada', true), (2, 'Grace', false), (3, 'Edsger', false), (4, 'Barbara', true)`)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		source string
+		ids    []int64
+	}{
+		{source: "select id from users where id = 2", ids: []int64{2}},
+		{source: "select id from users where name = 'Ada'", ids: []int64{1}},
+		{source: "select id from users where 3 = id", ids: []int64{3}},
+		{source: "select id from users where id <> 2", ids: []int64{1, 3, 4}},
+		{source: "select id from users where name != 'Ada'", ids: []int64{2, 3, 4}},
+		{source: "select id from users where id < 3", ids: []int64{1, 2}},
+		{source: "select id from users where id >= 3", ids: []int64{3, 4}},
+		{source: "select id from users where name > 'C'", ids: []int64{2, 3}},
+		{source: "select id from users where admin", ids: []int64{1, 4}},
+		{source: "select id from users where admin = false", ids: []int64{2, 3}},
+		{source: "select id from users where id > 1 and admin", ids: []int64{4}},
+		{source: "select id from users where id = 1 or name = 'Edsger'", ids: []int64{1, 3}},
+		{source: "select id from users where not admin", ids: []int64{2, 3}},
+		{source: "select id from users u where not (u.id = 1 or u.id = 2) and (name = 'Barbara' or id < 0)", ids: []int64{4}},
+		{source: "select id from users where id = 1 and id = 2", ids: nil},
+		{source: "select id from users where name = 'Alan'", ids: nil},
+		{source: "select id from users where false", ids: nil},
+		{source: "select id from users where 1 = 1", ids: []int64{1, 2, 3, 4}},
+	}
+	for _, test := range tests {
+		results, err := execute(mb, test.source)
+		assert.Nil(t, err, test.source)
+		var ids []int64
+		for _, row := range results.Rows {
+			id, ok := row[0].AsInt()
+			assert.True(t, ok, test.source)
+			ids = append(ids, id)
+		}
+		assert.Equal(t, test.ids, ids, test.source)
+	}
+
+	// where 中的比较也可以出现在选择列表里
+	results, err := execute(mb, "select id = 2 as two, not admin from users where id < 3")
+	assert.Nil(t, err)
+	assert.Equal(t, &Results{
+		Columns: []ResultColumn{{Name: "two", Type: BoolType}, {Name: "?column?", Type: BoolType}},
+		Rows:    [][]Cell{{boolCell(false), boolCell(false)}, {boolCell(true), boolCell(true)}},
+	}, results)
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select id from users where nick = 'Ada'",
+			err:    "column nick does not exist in table users at line 1 col 28",
+		},
+		{
+			source: "select id from users where id",
+			err:    "argument of WHERE must be type bool, not type int at line 1 col 28",
+		},
+		{
+			source: "select id from users where 'yes'",
+			err:    "argument of WHERE must be type bool, not type text at line 1 col 28",
+		},
+		{
+			source: "select id from users where id = '1'",
+			err:    "operator = is not defined for int and text at line 1 col 31",
+		},
+		{
+			source: "select id from users where admin and id",
+			err:    "argument of AND must be type bool, not type int at line 1 col 38",
+		},
+		{
+			source: "select id from users where name or admin",
+			err:    "argument of OR must be type bool, not type text at line 1 col 28",
+		},
+		{
+			source: "select id from users where not name",
+			err:    "argument of NOT must be type bool, not type text at line 1 col 32",
+		},
+		{
+			source: "select id from users where id + 1 = 2",
+			err:    "expression id + 1 is not supported at line 1 col 28",
+		},
+	}
+	for _, test := range errors {
+		_, err := execute(mb, test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
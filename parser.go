@@ -0,0 +1,2578 @@
+package gosql
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parse 对 source 进行词法分析，再把 token 解析成语法树。
+// 语句之间用分号分隔，最后一条语句的分号可以省略，
+// 连续的分号（空语句）会被忽略。
+func Parse(source string) (*Ast, error) {
+	return ParseWithOptions(source, ParseOptions{})
+}
+
+// DefaultMaxExpressionDepth 是 ParseOptions.MaxExpressionDepth 为零时的嵌套深度上限
+const DefaultMaxExpressionDepth = 200
+
+// ParseOptions 控制语法分析的行为，零值和 Parse 完全一致
+type ParseOptions struct {
+	// MaxExpressionDepth 限制表达式和子查询的嵌套深度，
+	// 防止用户输入的 SQL 让递归下降耗尽栈，为零时使用 DefaultMaxExpressionDepth，
+	// 不能是负数。括号、case 和每个前缀运算符（not、+、-）各算一层。
+	MaxExpressionDepth int
+}
+
+// ParseWithOptions 按照 opts 解析 source，遇到第一个错误就返回
+func ParseWithOptions(source string, opts ParseOptions) (*Ast, error) {
+	if opts.MaxExpressionDepth < 0 {
+		return nil, fmt.Errorf("MaxExpressionDepth must not be negative, got %d", opts.MaxExpressionDepth)
+	}
+
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTokens(tokens, opts)
+}
+
+// ParseAll 和 Parse 一样解析整个脚本，但遇到错误时不会停止：
+// 记录错误，跳到下一个分号后继续解析下一条语句。
+// 返回的语法树包含所有成功解析的语句，错误（包括词法错误）按位置排序。
+func ParseAll(source string) (*Ast, []error) {
+	tokens, errs := LexAll(source)
+
+	a, parseErrs := parseScript(tokens, ParseOptions{}, false)
+	errs = append(errs, parseErrs...)
+	sort.SliceStable(errs, func(i, j int) bool {
+		a, b := errorLocation(errs[i]), errorLocation(errs[j])
+		return a.Line < b.Line || (a.Line == b.Line && a.Col < b.Col)
+	})
+
+	return a, errs
+}
+
+func parseTokens(tokens []*Token, opts ParseOptions) (*Ast, error) {
+	a, errs := parseScript(tokens, opts, true)
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return a, nil
+}
+
+func parseScript(tokens []*Token, opts ParseOptions, failFast bool) (*Ast, []error) {
+	maxDepth := opts.MaxExpressionDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultMaxExpressionDepth
+	}
+
+	a := Ast{}
+	var errs []error
+	cursor := uint(0)
+	for cursor < uint(len(tokens)) {
+		if isSymbol(tokens, cursor, SemicolonSymbol) {
+			cursor++
+			continue
+		}
+
+		stmt, newCursor, err := parseStatement(tokens, &nesting{max: maxDepth}, cursor)
+		if err == nil {
+			stmt.Start, stmt.End = span(tokens, cursor, newCursor)
+			cursor = newCursor
+			a.Statements = append(a.Statements, stmt)
+
+			// 输入结束也可以结束语句
+			if cursor == uint(len(tokens)) {
+				break
+			}
+
+			cursor, err = expectSymbol(tokens, cursor, SemicolonSymbol)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			if failFast {
+				return nil, errs
+			}
+			cursor = skipToSemicolon(tokens, cursor)
+		}
+	}
+
+	return &a, errs
+}
+
+// nesting 记录正在解析的语句中表达式和子查询嵌套的层数，每条语句从零开始
+type nesting struct {
+	level int
+	max   int
+}
+
+// enter 进入 cursor 处的 token 开始的一层嵌套，超过上限时返回指向这个 token 的错误，
+// 不再继续递归。成功进入后，这一层解析完时要调用 leave。
+func (n *nesting) enter(tokens []*Token, cursor uint) error {
+	if n.level >= n.max {
+		return errorAt(tokens, cursor, fmt.Sprintf("expression nested deeper than the limit of %d", n.max))
+	}
+	n.level++
+	return nil
+}
+
+func (n *nesting) leave() {
+	n.level--
+}
+
+// skipToSemicolon 返回 cursor 之后（包括 cursor）第一个分号的位置，没有分号时返回末尾
+func skipToSemicolon(tokens []*Token, cursor uint) uint {
+	for cursor < uint(len(tokens)) && !isSymbol(tokens, cursor, SemicolonSymbol) {
+		cursor++
+	}
+	return cursor
+}
+
+func errorLocation(err error) Location {
+	switch e := err.(type) {
+	case *ParseError:
+		return e.Loc
+	case *LexError:
+		return e.Loc
+	}
+	return Location{}
+}
+
+// 语句的类型由第一个关键字决定，
+// 每个 parseXStatement 和 lexer 一样：不是自己的语句时返回 false。
+func parseStatement(tokens []*Token, depth *nesting, initialCursor uint) (*Statement, uint, error) {
+	cursor := initialCursor
+
+	query, newCursor, ok, err := parseSetExpression(tokens, depth, cursor, false)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if ok {
+		if query.Compound != nil {
+			return &Statement{
+				Kind:                    CompoundSelectKind,
+				CompoundSelectStatement: query.Compound,
+			}, newCursor, nil
+		}
+		return &Statement{
+			Kind:            SelectKind,
+			SelectStatement: query.Select,
+		}, newCursor, nil
+	}
+
+	inst, newCursor, ok, err := parseInsertStatement(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if ok {
+		return &Statement{
+			Kind:            InsertKind,
+			InsertStatement: inst,
+		}, newCursor, nil
+	}
+
+	crtIdx, newCursor, ok, err := parseCreateIndexStatement(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if ok {
+		return &Statement{
+			Kind:                 CreateIndexKind,
+			CreateIndexStatement: crtIdx,
+		}, newCursor, nil
+	}
+
+	crtView, newCursor, ok, err := parseCreateViewStatement(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if ok {
+		return &Statement{
+			Kind:                CreateViewKind,
+			CreateViewStatement: crtView,
+		}, newCursor, nil
+	}
+
+	crtTbl, newCursor, ok, err := parseCreateTableStatement(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if ok {
+		return &Statement{
+			Kind:                 CreateTableKind,
+			CreateTableStatement: crtTbl,
+		}, newCursor, nil
+	}
+
+	dropIdx, newCursor, ok, err := parseDropIndexStatement(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if ok {
+		return &Statement{
+			Kind:               DropIndexKind,
+			DropIndexStatement: dropIdx,
+		}, newCursor, nil
+	}
+
+	dropView, newCursor, ok, err := parseDropViewStatement(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if ok {
+		return &Statement{
+			Kind:              DropViewKind,
+			DropViewStatement: dropView,
+		}, newCursor, nil
+	}
+
+	dlt, newCursor, ok, err := parseDeleteStatement(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if ok {
+		return &Statement{
+			Kind:            DeleteKind,
+			DeleteStatement: dlt,
+		}, newCursor, nil
+	}
+
+	updt, newCursor, ok, err := parseUpdateStatement(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if ok {
+		return &Statement{
+			Kind:            UpdateKind,
+			UpdateStatement: updt,
+		}, newCursor, nil
+	}
+
+	values, newCursor, ok, err := parseValuesStatement(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if ok {
+		return &Statement{
+			Kind:            ValuesKind,
+			ValuesStatement: values,
+		}, newCursor, nil
+	}
+
+	explain, newCursor, ok, err := parseExplainStatement(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if ok {
+		return &Statement{
+			Kind:             ExplainKind,
+			ExplainStatement: explain,
+		}, newCursor, nil
+	}
+
+	show, newCursor, ok, err := parseShowStatement(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if ok {
+		return &Statement{
+			Kind:          ShowKind,
+			ShowStatement: show,
+		}, newCursor, nil
+	}
+
+	describe, newCursor, ok, err := parseDescribeStatement(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if ok {
+		return &Statement{
+			Kind:              DescribeKind,
+			DescribeStatement: describe,
+		}, newCursor, nil
+	}
+
+	if kind, newCursor, ok := parseTransactionStatement(tokens, cursor); ok {
+		return &Statement{Kind: kind}, newCursor, nil
+	}
+
+	trunc, newCursor, ok, err := parseTruncateStatement(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if ok {
+		return &Statement{
+			Kind:              TruncateKind,
+			TruncateStatement: trunc,
+		}, newCursor, nil
+	}
+
+	return nil, initialCursor, expected(tokens, cursor, "SELECT, INSERT, CREATE, DROP, DELETE, UPDATE, VALUES, TRUNCATE, BEGIN, COMMIT, ROLLBACK, EXPLAIN, SHOW or DESCRIBE")
+}
+
+// select [distinct] <表达式>[, <表达式> ...] [from <表名>] [where <表达式>]
+// [group by <表达式>[, ...]] [having <表达式>]
+// [order by <表达式> [asc | desc][, ...]] [limit <行数>] [offset <行数>]
+func parseSelectStatement(tokens []*Token, depth *nesting, initialCursor uint) (*SelectStatement, uint, bool, error) {
+	return parseSelect(tokens, depth, initialCursor, false)
+}
+
+// 集合运算中 intersect 比 union 和 except 结合得紧，同级的从左到右结合，
+// 括号可以改变结合的顺序：
+//
+//	a union b intersect c    是 a union (b intersect c)
+//	a except b union c       是 (a except b) union c
+//
+// 最后一个 select 后面的 order by、limit 和 offset 属于整个集合运算，
+// 前面的 select 要有这些子句必须加括号。
+func parseSetExpression(tokens []*Token, depth *nesting, initialCursor uint, nested bool) (*SetOperand, uint, bool, error) {
+	query, cursor, ok, err := parseSetOperation(tokens, depth, initialCursor, nested, []Keyword{UnionKeyword, ExceptKeyword}, parseSetTerm)
+	if err != nil || !ok {
+		return nil, initialCursor, ok, err
+	}
+
+	// 操作数里的 select 在这些子句前就结束了，子句从 cursor 开始，
+	// 只有一个 select 时属于它，否则属于整个集合运算
+	orderBy, limit, offset, newCursor, err := parseOrderByClauses(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	if newCursor == cursor {
+		return query, cursor, true, nil
+	}
+	if isKeyword(tokens, newCursor, OrderKeyword) && (limit != nil || offset != nil) {
+		return nil, initialCursor, false, errorAt(tokens, newCursor, "ORDER BY must come before LIMIT and OFFSET")
+	}
+	if isSetOperator(tokens, newCursor, setOperators) {
+		op := strings.ToUpper(tokens[newCursor].Value)
+		return nil, initialCursor, false, errorAt(tokens, newCursor, fmt.Sprintf("ORDER BY, LIMIT or OFFSET before %s must be in parentheses", op))
+	}
+
+	if c := query.Compound; c != nil {
+		c.OrderBy, c.Limit, c.Offset = orderBy, limit, offset
+		c.End = tokens[newCursor-1].End
+		return query, newCursor, true, nil
+	}
+
+	// (select ...) order by ...，括号里已经有这些子句时无法合并
+	s := query.Select
+	if s.OrderBy != nil || s.Limit != nil || s.Offset != nil {
+		return nil, initialCursor, false, errorAt(tokens, cursor, "multiple ORDER BY, LIMIT or OFFSET clauses are not allowed")
+	}
+	s.OrderBy, s.Limit, s.Offset = orderBy, limit, offset
+	s.End = tokens[newCursor-1].End
+	return query, newCursor, true, nil
+}
+
+func parseSetTerm(tokens []*Token, depth *nesting, initialCursor uint, nested bool) (*SetOperand, uint, bool, error) {
+	return parseSetOperation(tokens, depth, initialCursor, nested, []Keyword{IntersectKeyword}, parseSetPrimary)
+}
+
+// 集合运算的操作数是不带 order by、limit 和 offset 的 select 或者括号里的集合运算
+func parseSetPrimary(tokens []*Token, depth *nesting, initialCursor uint, nested bool) (*SetOperand, uint, bool, error) {
+	if !isSymbol(tokens, initialCursor, LeftParenSymbol) {
+		slct, cursor, ok, err := parseSelectCore(tokens, depth, initialCursor, nested)
+		if err != nil || !ok {
+			return nil, initialCursor, ok, err
+		}
+		return &SetOperand{Select: slct}, cursor, true, nil
+	}
+
+	if err := depth.enter(tokens, initialCursor); err != nil {
+		return nil, initialCursor, false, err
+	}
+	defer depth.leave()
+
+	query, cursor, ok, err := parseSetExpression(tokens, depth, initialCursor+1, true)
+	if err != nil || !ok {
+		return nil, initialCursor, ok, err
+	}
+
+	if !isSymbol(tokens, cursor, RightParenSymbol) {
+		open := tokens[initialCursor].Loc
+		return nil, initialCursor, false, expected(tokens, cursor, fmt.Sprintf("')' to close '(' from line %d col %d", open.Line+1, open.Col+1))
+	}
+	return query, cursor + 1, true, nil
+}
+
+type setOperandParser func(tokens []*Token, depth *nesting, initialCursor uint, nested bool) (*SetOperand, uint, bool, error)
+
+// 解析用 ops 中的运算符连接起来的操作数，只有一个操作数时直接返回它
+func parseSetOperation(tokens []*Token, depth *nesting, initialCursor uint, nested bool, ops []Keyword, parseOperand setOperandParser) (*SetOperand, uint, bool, error) {
+	first, cursor, ok, err := parseOperand(tokens, depth, initialCursor, nested)
+	if err != nil || !ok {
+		return nil, initialCursor, ok, err
+	}
+
+	compound := CompoundSelectStatement{Selects: []*SetOperand{first}}
+	for isSetOperator(tokens, cursor, ops) {
+		op := tokens[cursor]
+		cursor++
+
+		all := isKeyword(tokens, cursor, AllKeyword)
+		if all {
+			cursor++
+		}
+
+		operand, newCursor, ok, err := parseOperand(tokens, depth, cursor, nested)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		if !ok {
+			return nil, initialCursor, false, expected(tokens, cursor, "SELECT or '('")
+		}
+		cursor = newCursor
+
+		compound.Selects = append(compound.Selects, operand)
+		compound.Ops = append(compound.Ops, &SetOperator{Op: *op, All: all})
+	}
+
+	if len(compound.Ops) == 0 {
+		return first, cursor, true, nil
+	}
+	compound.Start, compound.End = span(tokens, initialCursor, cursor)
+	return &SetOperand{Compound: &compound}, cursor, true, nil
+}
+
+var setOperators = []Keyword{UnionKeyword, IntersectKeyword, ExceptKeyword}
+
+func isSetOperator(tokens []*Token, cursor uint, ops []Keyword) bool {
+	for _, k := range ops {
+		if isKeyword(tokens, cursor, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// 括号里的子查询 (select ...)，调用方已经确认 initialCursor 处是左括号
+func parseSubquery(tokens []*Token, depth *nesting, initialCursor uint) (*SelectStatement, uint, error) {
+	if err := depth.enter(tokens, initialCursor); err != nil {
+		return nil, initialCursor, err
+	}
+	defer depth.leave()
+
+	slct, cursor, ok, err := parseSelect(tokens, depth, initialCursor+1, true)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if !ok {
+		return nil, initialCursor, expected(tokens, initialCursor+1, "SELECT")
+	}
+
+	if !isSymbol(tokens, cursor, RightParenSymbol) {
+		open := tokens[initialCursor].Loc
+		return nil, initialCursor, expected(tokens, cursor, fmt.Sprintf("')' to close '(' from line %d col %d", open.Line+1, open.Col+1))
+	}
+	return slct, cursor + 1, nil
+}
+
+// nested 表示 select 在括号里，这时它可以在右括号前结束
+func parseSelect(tokens []*Token, depth *nesting, initialCursor uint, nested bool) (*SelectStatement, uint, bool, error) {
+	slct, cursor, ok, err := parseSelectCore(tokens, depth, initialCursor, nested)
+	if err != nil || !ok {
+		return nil, initialCursor, ok, err
+	}
+
+	slct.OrderBy, slct.Limit, slct.Offset, cursor, err = parseOrderByClauses(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	if isKeyword(tokens, cursor, OrderKeyword) && (slct.Limit != nil || slct.Offset != nil) {
+		return nil, initialCursor, false, errorAt(tokens, cursor, "ORDER BY must come before LIMIT and OFFSET")
+	}
+
+	slct.Start, slct.End = span(tokens, initialCursor, cursor)
+	return slct, cursor, true, nil
+}
+
+// parseSelectCore 解析 select 到 having 为止的部分，不包括 order by、limit 和 offset
+func parseSelectCore(tokens []*Token, depth *nesting, initialCursor uint, nested bool) (*SelectStatement, uint, bool, error) {
+	cursor := initialCursor
+	if !isKeyword(tokens, cursor, SelectKeyword) {
+		return nil, initialCursor, false, nil
+	}
+	cursor++
+
+	slct := SelectStatement{}
+	if isKeyword(tokens, cursor, DistinctKeyword) {
+		slct.Distinct = true
+		cursor++
+	}
+
+	items, cursor, err := parseSelectItems(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	slct.Item = items
+
+	// from 可以省略，比如 select 1 + 1，这时 From 为 nil。
+	// 子查询没有 from 时投影列后面是右括号
+	if isKeyword(tokens, cursor, FromKeyword) {
+		from, newCursor, err := parseTableReference(tokens, depth, cursor+1)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		slct.From = from
+		cursor = newCursor
+
+		joins, newCursor, err := parseJoins(tokens, depth, cursor)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		slct.Joins = joins
+		cursor = newCursor
+	} else if cursor < uint(len(tokens)) &&
+		!isSymbol(tokens, cursor, SemicolonSymbol) &&
+		!(nested && isSymbol(tokens, cursor, RightParenSymbol)) &&
+		!isSelectClause(tokens, cursor) &&
+		!isSetOperator(tokens, cursor, setOperators) {
+		return nil, initialCursor, false, expected(tokens, cursor, "',', FROM or ';'")
+	}
+
+	where, cursor, err := parseWhere(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	slct.Where = where
+
+	if isKeyword(tokens, cursor, GroupKeyword) {
+		cursor, err = expectKeyword(tokens, cursor+1, ByKeyword)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		groupBy, newCursor, err := parseExpressions(tokens, depth, cursor)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		slct.GroupBy = groupBy
+		cursor = newCursor
+	}
+
+	// 没有 group by 时 having 作用在整个结果这一个分组上
+	if isKeyword(tokens, cursor, HavingKeyword) {
+		having, newCursor, ok, err := parseExpression(tokens, depth, cursor+1)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		if !ok {
+			return nil, initialCursor, false, expected(tokens, cursor+1, "expression")
+		}
+		slct.Having = having
+		cursor = newCursor
+	}
+
+	slct.Start, slct.End = span(tokens, initialCursor, cursor)
+	return &slct, cursor, true, nil
+}
+
+// limit 和 offset 后面的行数只能是非负整数或者参数
+func parseRowCount(tokens []*Token, depth *nesting, initialCursor uint, clause Keyword) (*Expression, uint, error) {
+	exp, cursor, ok, err := parseExpression(tokens, depth, initialCursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if !ok {
+		return nil, initialCursor, expected(tokens, initialCursor, "row count after "+strings.ToUpper(string(clause)))
+	}
+
+	if exp.Kind == LiteralKind {
+		switch exp.Literal.Kind {
+		case ParameterKind, NamedParameterKind:
+			return exp, cursor, nil
+		case NumericKind:
+			// 和 LiteralCell 一样按十进制解析，只有 0x 开头时是十六进制，09 不是八进制
+			value, base := exp.Literal.Value, 10
+			if strings.HasPrefix(value, "0x") || strings.HasPrefix(value, "0X") {
+				base = 0
+			}
+			if _, err := strconv.ParseUint(value, base, 64); err == nil {
+				return exp, cursor, nil
+			}
+		}
+	}
+	return nil, initialCursor, errorAt(tokens, initialCursor, fmt.Sprintf("%s must be a non-negative integer or a parameter", strings.ToUpper(string(clause))))
+}
+
+// 投影列之后可以直接开始的子句，from 省略时用来判断投影列是否已经结束
+var selectClauses = []Keyword{WhereKeyword, GroupKeyword, HavingKeyword, OrderKeyword, LimitKeyword, OffsetKeyword}
+
+func isSelectClause(tokens []*Token, cursor uint) bool {
+	for _, k := range selectClauses {
+		if isKeyword(tokens, cursor, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// [order by ...] [limit <行数>] [offset <行数>]，select 和集合运算的结尾都是这几个子句
+func parseOrderByClauses(tokens []*Token, depth *nesting, initialCursor uint) ([]*OrderByItem, *Expression, *Expression, uint, error) {
+	cursor := initialCursor
+	var orderBy []*OrderByItem
+	var limit, offset *Expression
+	var err error
+
+	if isKeyword(tokens, cursor, OrderKeyword) {
+		cursor, err = expectKeyword(tokens, cursor+1, ByKeyword)
+		if err != nil {
+			return nil, nil, nil, initialCursor, err
+		}
+		orderBy, cursor, err = parseOrderByItems(tokens, depth, cursor)
+		if err != nil {
+			return nil, nil, nil, initialCursor, err
+		}
+	}
+
+	if isKeyword(tokens, cursor, LimitKeyword) {
+		limit, cursor, err = parseRowCount(tokens, depth, cursor+1, LimitKeyword)
+		if err != nil {
+			return nil, nil, nil, initialCursor, err
+		}
+	}
+
+	if isKeyword(tokens, cursor, OffsetKeyword) {
+		offset, cursor, err = parseRowCount(tokens, depth, cursor+1, OffsetKeyword)
+		if err != nil {
+			return nil, nil, nil, initialCursor, err
+		}
+	}
+
+	return orderBy, limit, offset, cursor, nil
+}
+
+// 逗号分隔的排序项，每项是表达式加上可选的 asc 或 desc，默认升序
+func parseOrderByItems(tokens []*Token, depth *nesting, initialCursor uint) ([]*OrderByItem, uint, error) {
+	cursor := initialCursor
+
+	items := []*OrderByItem{}
+	for {
+		exp, newCursor, ok, err := parseExpression(tokens, depth, cursor)
+		if err != nil {
+			return nil, initialCursor, err
+		}
+		if !ok {
+			return nil, initialCursor, expected(tokens, cursor, "expression")
+		}
+		cursor = newCursor
+
+		item := OrderByItem{Exp: exp, Asc: true}
+		if isKeyword(tokens, cursor, DescKeyword) {
+			item.Asc = false
+			cursor++
+		} else if isKeyword(tokens, cursor, AscKeyword) {
+			cursor++
+		}
+		items = append(items, &item)
+
+		if !isSymbol(tokens, cursor, CommaSymbol) {
+			return items, cursor, nil
+		}
+		cursor++
+	}
+}
+
+// 可选的 where <表达式>，没有 where 时返回 nil
+func parseWhere(tokens []*Token, depth *nesting, initialCursor uint) (*Expression, uint, error) {
+	if !isKeyword(tokens, initialCursor, WhereKeyword) {
+		return nil, initialCursor, nil
+	}
+
+	where, cursor, ok, err := parseExpression(tokens, depth, initialCursor+1)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if !ok {
+		return nil, initialCursor, expected(tokens, initialCursor+1, "expression")
+	}
+	return where, cursor, nil
+}
+
+// 投影列是逗号分隔的表达式或 *，* 可以和其他列混用，比如 select *, id。
+// 表达式后面可以跟别名。
+func parseSelectItems(tokens []*Token, depth *nesting, initialCursor uint) ([]*SelectItem, uint, error) {
+	cursor := initialCursor
+
+	items := []*SelectItem{}
+	for {
+		if isSymbol(tokens, cursor, AsteriskSymbol) {
+			items = append(items, &SelectItem{Asterisk: true})
+			cursor++
+		} else {
+			exp, newCursor, ok, err := parseExpression(tokens, depth, cursor)
+			if err != nil {
+				return nil, initialCursor, err
+			}
+			if !ok {
+				return nil, initialCursor, expected(tokens, cursor, "expression")
+			}
+			cursor = newCursor
+
+			as, newCursor, err := parseAlias(tokens, cursor)
+			if err != nil {
+				return nil, initialCursor, err
+			}
+			cursor = newCursor
+
+			items = append(items, &SelectItem{Exp: exp, As: as})
+		}
+
+		if !isSymbol(tokens, cursor, CommaSymbol) {
+			return items, cursor, nil
+		}
+		cursor++
+	}
+}
+
+// 表引用是表名加上可选的别名，比如 users u 或 users as u，
+// 也可以是括号里的子查询或 values，这时必须有别名，别名后面还可以给出列名：
+// (select ...) as t 或 (values (1, 'a')) as v(id, name)
+func parseTableReference(tokens []*Token, depth *nesting, initialCursor uint) (*TableReference, uint, error) {
+	if isSymbol(tokens, initialCursor, LeftParenSymbol) {
+		return parseDerivedTable(tokens, depth, initialCursor)
+	}
+
+	name, cursor, err := expectIdentifier(tokens, initialCursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+
+	alias, cursor, err := parseAlias(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+
+	return &TableReference{Name: name, Alias: alias}, cursor, nil
+}
+
+func parseDerivedTable(tokens []*Token, depth *nesting, initialCursor uint) (*TableReference, uint, error) {
+	ref := TableReference{}
+	arity := 0
+
+	var cursor uint
+	switch {
+	case isKeyword(tokens, initialCursor+1, SelectKeyword):
+		slct, newCursor, err := parseSubquery(tokens, depth, initialCursor)
+		if err != nil {
+			return nil, initialCursor, err
+		}
+		ref.Select = slct
+		if !hasAsterisk(slct.Item) {
+			arity = len(slct.Item)
+		}
+		cursor = newCursor
+	case isKeyword(tokens, initialCursor+1, ValuesKeyword):
+		values, newCursor, _, err := parseValuesStatement(tokens, depth, initialCursor+1)
+		if err != nil {
+			return nil, initialCursor, err
+		}
+		if !isSymbol(tokens, newCursor, RightParenSymbol) {
+			open := tokens[initialCursor].Loc
+			return nil, initialCursor, expected(tokens, newCursor, fmt.Sprintf("')' to close '(' from line %d col %d", open.Line+1, open.Col+1))
+		}
+		ref.Values = values
+		arity = len(values.Rows[0])
+		cursor = newCursor + 1
+	default:
+		return nil, initialCursor, expected(tokens, initialCursor+1, "SELECT or VALUES")
+	}
+
+	alias, newCursor, err := parseAlias(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if alias == nil {
+		return nil, initialCursor, errorAt(tokens, cursor, "subquery in FROM must have an alias")
+	}
+	ref.Alias = alias
+	cursor = newCursor
+
+	if isSymbol(tokens, cursor, LeftParenSymbol) {
+		cols, newCursor, err := parseColumnList(tokens, cursor+1, alias)
+		if err != nil {
+			return nil, initialCursor, err
+		}
+		// 列名可以比实际的列少，剩下的列保留原来的名字
+		if arity > 0 && len(cols) > arity {
+			return nil, initialCursor, errorAt(tokens, cursor, fmt.Sprintf("%s has %d columns but %d column names", alias.Value, arity, len(cols)))
+		}
+		ref.Cols = cols
+		cursor = newCursor
+	}
+
+	return &ref, cursor, nil
+}
+
+var outerJoinKinds = map[Keyword]JoinKind{
+	LeftKeyword:  LeftJoin,
+	RightKeyword: RightJoin,
+	FullKeyword:  FullJoin,
+}
+
+// from 后面零个或多个 <连接类型> join <表引用> on <表达式>，连接类型是
+// 空、inner 或者 left、right、full 加上可选的 outer
+func parseJoins(tokens []*Token, depth *nesting, initialCursor uint) ([]*Join, uint, error) {
+	cursor := initialCursor
+
+	var joins []*Join
+	for {
+		kind := InnerJoin
+		switch {
+		case isKeyword(tokens, cursor, JoinKeyword):
+			cursor++
+		case isKeyword(tokens, cursor, InnerKeyword):
+			newCursor, err := expectKeyword(tokens, cursor+1, JoinKeyword)
+			if err != nil {
+				return nil, initialCursor, err
+			}
+			cursor = newCursor
+		case cursor < uint(len(tokens)) && tokens[cursor].Kind == KeywordKind:
+			outer, ok := outerJoinKinds[Keyword(tokens[cursor].Value)]
+			if !ok {
+				return joins, cursor, nil
+			}
+			kind = outer
+			cursor++
+			if isKeyword(tokens, cursor, OuterKeyword) {
+				cursor++
+			}
+			newCursor, err := expectKeyword(tokens, cursor, JoinKeyword)
+			if err != nil {
+				return nil, initialCursor, err
+			}
+			cursor = newCursor
+		default:
+			return joins, cursor, nil
+		}
+
+		table, newCursor, err := parseTableReference(tokens, depth, cursor)
+		if err != nil {
+			return nil, initialCursor, err
+		}
+
+		cursor, err = expectKeyword(tokens, newCursor, OnKeyword)
+		if err != nil {
+			return nil, initialCursor, err
+		}
+
+		on, newCursor, ok, err := parseExpression(tokens, depth, cursor)
+		if err != nil {
+			return nil, initialCursor, err
+		}
+		if !ok {
+			return nil, initialCursor, expected(tokens, cursor, "expression")
+		}
+		cursor = newCursor
+
+		joins = append(joins, &Join{Kind: kind, Table: table, On: on})
+	}
+}
+
+// 别名可以写成 as name，也可以省略 as 直接写 name，
+// 关键字不能不加引号直接当作别名。没有别名时返回 nil。
+func parseAlias(tokens []*Token, initialCursor uint) (*Token, uint, error) {
+	cursor := initialCursor
+	if isKeyword(tokens, cursor, AsKeyword) {
+		alias, cursor, err := expectIdentifier(tokens, cursor+1)
+		if err != nil {
+			return nil, initialCursor, err
+		}
+		return alias, cursor, nil
+	}
+
+	if cursor < uint(len(tokens)) && tokens[cursor].Kind == IdentifierKind {
+		return tokens[cursor], cursor + 1, nil
+	}
+
+	return nil, initialCursor, nil
+}
+
+// insert into <表名> [(<列名>[, <列名> ...])] values (<表达式>[, <表达式> ...])[, (...) ...]
+// insert into <表名> [(<列名>[, <列名> ...])] select ...
+func parseInsertStatement(tokens []*Token, depth *nesting, initialCursor uint) (*InsertStatement, uint, bool, error) {
+	cursor := initialCursor
+	if !isKeyword(tokens, cursor, InsertKeyword) {
+		return nil, initialCursor, false, nil
+	}
+	cursor++
+
+	cursor, err := expectKeyword(tokens, cursor, IntoKeyword)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	table, cursor, err := expectIdentifier(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	var cols []Token
+	if isSymbol(tokens, cursor, LeftParenSymbol) {
+		cols, cursor, err = parseColumnList(tokens, cursor+1, table)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+	}
+
+	inst := InsertStatement{
+		Table: *table,
+		Cols:  cols,
+	}
+
+	// 值来自 select 时不能再有 values，反过来也一样
+	if isKeyword(tokens, cursor, SelectKeyword) {
+		slct, newCursor, _, err := parseSelectStatement(tokens, depth, cursor)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		if cols != nil && len(cols) != len(slct.Item) && !hasAsterisk(slct.Item) {
+			return nil, initialCursor, false, errorAt(tokens, cursor, fmt.Sprintf("insert into %s has %d columns but select has %d", table.Value, len(cols), len(slct.Item)))
+		}
+		inst.Select = slct
+		cursor = newCursor
+
+		if isKeyword(tokens, cursor, ValuesKeyword) {
+			return nil, initialCursor, false, errorAt(tokens, cursor, fmt.Sprintf("insert into %s cannot have both SELECT and VALUES", table.Value))
+		}
+		inst.Start, inst.End = span(tokens, initialCursor, cursor)
+		return &inst, cursor, true, nil
+	}
+
+	if !isKeyword(tokens, cursor, ValuesKeyword) {
+		return nil, initialCursor, false, expected(tokens, cursor, "VALUES or SELECT")
+	}
+	cursor++
+
+	values, cursor, err := parseValueRows(tokens, depth, cursor, "insert into "+table.Value, cols)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	inst.Values = values
+
+	if isKeyword(tokens, cursor, SelectKeyword) {
+		return nil, initialCursor, false, errorAt(tokens, cursor, fmt.Sprintf("insert into %s cannot have both VALUES and SELECT", table.Value))
+	}
+
+	inst.Start, inst.End = span(tokens, initialCursor, cursor)
+	return &inst, cursor, true, nil
+}
+
+func hasAsterisk(items []*SelectItem) bool {
+	for _, item := range items {
+		if item.Asterisk {
+			return true
+		}
+	}
+	return false
+}
+
+// values (<表达式>[, ...])[, (...) ...]
+func parseValuesStatement(tokens []*Token, depth *nesting, initialCursor uint) (*ValuesStatement, uint, bool, error) {
+	if !isKeyword(tokens, initialCursor, ValuesKeyword) {
+		return nil, initialCursor, false, nil
+	}
+
+	rows, cursor, err := parseValueRows(tokens, depth, initialCursor+1, "VALUES", nil)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	start, end := span(tokens, initialCursor, cursor)
+	return &ValuesStatement{Rows: rows, Start: start, End: end}, cursor, true, nil
+}
+
+// 逗号分隔的若干行值，每行是括号里的表达式列表。
+// 每行的值的个数都要和列数相同，没有列名列表时和第一行相同。
+// what 是错误信息里的主语，比如 insert into t。
+func parseValueRows(tokens []*Token, depth *nesting, initialCursor uint, what string, cols []Token) ([][]*Expression, uint, error) {
+	cursor := initialCursor
+
+	rows := [][]*Expression{}
+	for {
+		open := cursor
+		if !isSymbol(tokens, cursor, LeftParenSymbol) {
+			return nil, initialCursor, expected(tokens, cursor, "'('")
+		}
+
+		row, newCursor, err := parseExpressions(tokens, depth, cursor+1)
+		if err != nil {
+			return nil, initialCursor, err
+		}
+		cursor = newCursor
+
+		if !isSymbol(tokens, cursor, RightParenSymbol) {
+			return nil, initialCursor, expected(tokens, cursor, "',' or ')'")
+		}
+		cursor++
+
+		switch {
+		case cols != nil && len(row) != len(cols):
+			return nil, initialCursor, errorAt(tokens, open, fmt.Sprintf("%s has %d columns but %d values", what, len(cols), len(row)))
+		case cols == nil && len(rows) > 0 && len(row) != len(rows[0]):
+			return nil, initialCursor, errorAt(tokens, open, fmt.Sprintf("%s row %d has %d values but row 1 has %d", what, len(rows)+1, len(row), len(rows[0])))
+		}
+		rows = append(rows, row)
+
+		if !isSymbol(tokens, cursor, CommaSymbol) {
+			return rows, cursor, nil
+		}
+		cursor++
+	}
+}
+
+// 括号里逗号分隔的列名，从左括号后面一直读到右括号（包括右括号），
+// 用于 insert 的列名列表和表级约束
+func parseColumnList(tokens []*Token, initialCursor uint, table *Token) ([]Token, uint, error) {
+	cursor := initialCursor
+
+	cols := []Token{}
+	for {
+		col, newCursor, err := expectIdentifier(tokens, cursor)
+		if err != nil {
+			if len(cols) > 0 {
+				return nil, initialCursor, expected(tokens, cursor, fmt.Sprintf("column name after ',' in column list of %s", table.Value))
+			}
+			return nil, initialCursor, err
+		}
+		cursor = newCursor
+		cols = append(cols, *col)
+
+		if isSymbol(tokens, cursor, RightParenSymbol) {
+			return cols, cursor + 1, nil
+		}
+		if !isSymbol(tokens, cursor, CommaSymbol) {
+			return nil, initialCursor, expected(tokens, cursor, "',' or ')'")
+		}
+		cursor++
+	}
+}
+
+// create [or replace] view <视图名> as <select>
+func parseCreateViewStatement(tokens []*Token, depth *nesting, initialCursor uint) (*CreateViewStatement, uint, bool, error) {
+	cursor := initialCursor
+	if !isKeyword(tokens, cursor, CreateKeyword) ||
+		(!isKeyword(tokens, cursor+1, ViewKeyword) && !isKeyword(tokens, cursor+1, OrKeyword)) {
+		return nil, initialCursor, false, nil
+	}
+	cursor++
+
+	crtView := CreateViewStatement{}
+	var err error
+	if isKeyword(tokens, cursor, OrKeyword) {
+		cursor, err = expectKeyword(tokens, cursor+1, ReplaceKeyword)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		crtView.OrReplace = true
+	}
+
+	cursor, err = expectKeyword(tokens, cursor, ViewKeyword)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	name, cursor, err := expectIdentifier(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	crtView.Name = *name
+
+	if !isKeyword(tokens, cursor, AsKeyword) {
+		return nil, initialCursor, false, expected(tokens, cursor, "AS and the SELECT of view "+name.Value)
+	}
+	cursor++
+
+	slct, cursor, ok, err := parseSelectStatement(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	if !ok {
+		return nil, initialCursor, false, expected(tokens, cursor, "SELECT of view "+name.Value)
+	}
+	crtView.Select = slct
+
+	crtView.Start, crtView.End = span(tokens, initialCursor, cursor)
+	return &crtView, cursor, true, nil
+}
+
+// drop view <视图名>
+func parseDropViewStatement(tokens []*Token, initialCursor uint) (*DropViewStatement, uint, bool, error) {
+	if !isKeyword(tokens, initialCursor, DropKeyword) {
+		return nil, initialCursor, false, nil
+	}
+
+	if !isKeyword(tokens, initialCursor+1, ViewKeyword) {
+		return nil, initialCursor, false, expected(tokens, initialCursor+1, "VIEW or INDEX")
+	}
+
+	name, cursor, err := expectIdentifier(tokens, initialCursor+2)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	start, end := span(tokens, initialCursor, cursor)
+	return &DropViewStatement{Name: *name, Start: start, End: end}, cursor, true, nil
+}
+
+// drop index [if exists] <索引名> [on <表名>]
+func parseDropIndexStatement(tokens []*Token, initialCursor uint) (*DropIndexStatement, uint, bool, error) {
+	if !isKeyword(tokens, initialCursor, DropKeyword) || !isKeyword(tokens, initialCursor+1, IndexKeyword) {
+		return nil, initialCursor, false, nil
+	}
+	cursor := initialCursor + 2
+
+	var err error
+
+	dropIdx := DropIndexStatement{}
+	if isKeyword(tokens, cursor, IfKeyword) {
+		cursor, err = expectKeyword(tokens, cursor+1, ExistsKeyword)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		dropIdx.IfExists = true
+	}
+
+	name, cursor, err := expectIdentifier(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	dropIdx.Name = *name
+
+	if isKeyword(tokens, cursor, OnKeyword) {
+		dropIdx.Table, cursor, err = expectIdentifier(tokens, cursor+1)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+	}
+
+	dropIdx.Start, dropIdx.End = span(tokens, initialCursor, cursor)
+	return &dropIdx, cursor, true, nil
+}
+
+// delete from <表名> [where <表达式>]
+func parseDeleteStatement(tokens []*Token, depth *nesting, initialCursor uint) (*DeleteStatement, uint, bool, error) {
+	cursor := initialCursor
+	if !isKeyword(tokens, cursor, DeleteKeyword) {
+		return nil, initialCursor, false, nil
+	}
+	cursor++
+
+	cursor, err := expectKeyword(tokens, cursor, FromKeyword)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	table, cursor, err := expectIdentifier(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	where, cursor, err := parseWhere(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	start, end := span(tokens, initialCursor, cursor)
+	return &DeleteStatement{
+		From:  *table,
+		Where: where,
+		Start: start,
+		End:   end,
+	}, cursor, true, nil
+}
+
+// explain [analyze] <语句>，被解释的语句不能是另一个 explain
+func parseExplainStatement(tokens []*Token, depth *nesting, initialCursor uint) (*ExplainStatement, uint, bool, error) {
+	cursor := initialCursor
+	if !isKeyword(tokens, cursor, ExplainKeyword) {
+		return nil, initialCursor, false, nil
+	}
+	cursor++
+
+	explain := ExplainStatement{}
+	if isKeyword(tokens, cursor, AnalyzeKeyword) {
+		explain.Analyze = true
+		cursor++
+	}
+
+	if isKeyword(tokens, cursor, ExplainKeyword) {
+		return nil, initialCursor, false, errorAt(tokens, cursor, "cannot EXPLAIN an EXPLAIN statement")
+	}
+
+	stmt, newCursor, err := parseStatement(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	stmt.Start, stmt.End = span(tokens, cursor, newCursor)
+	explain.Statement = stmt
+
+	explain.Start, explain.End = span(tokens, initialCursor, newCursor)
+	return &explain, newCursor, true, nil
+}
+
+// show tables
+func parseShowStatement(tokens []*Token, initialCursor uint) (*ShowStatement, uint, bool, error) {
+	if !isKeyword(tokens, initialCursor, ShowKeyword) {
+		return nil, initialCursor, false, nil
+	}
+
+	cursor, err := expectKeyword(tokens, initialCursor+1, TablesKeyword)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	start, end := span(tokens, initialCursor, cursor)
+	return &ShowStatement{Target: ShowTables, Start: start, End: end}, cursor, true, nil
+}
+
+// describe <表名> 或 desc <表名>。desc 也是 order by 里的降序关键字，
+// 但只有在语句开头才会当作 describe
+func parseDescribeStatement(tokens []*Token, initialCursor uint) (*DescribeStatement, uint, bool, error) {
+	if !isKeyword(tokens, initialCursor, DescribeKeyword) && !isKeyword(tokens, initialCursor, DescKeyword) {
+		return nil, initialCursor, false, nil
+	}
+
+	table, cursor, err := expectIdentifier(tokens, initialCursor+1)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	start, end := span(tokens, initialCursor, cursor)
+	return &DescribeStatement{Table: *table, Start: start, End: end}, cursor, true, nil
+}
+
+var transactionKinds = map[Keyword]AstKind{
+	BeginKeyword:    BeginKind,
+	CommitKeyword:   CommitKind,
+	RollbackKeyword: RollbackKind,
+}
+
+// begin、commit 或 rollback，后面可以跟没有意义的 transaction 或 work
+func parseTransactionStatement(tokens []*Token, initialCursor uint) (AstKind, uint, bool) {
+	cursor := initialCursor
+	if cursor >= uint(len(tokens)) || tokens[cursor].Kind != KeywordKind {
+		return 0, initialCursor, false
+	}
+	kind, ok := transactionKinds[Keyword(tokens[cursor].Value)]
+	if !ok {
+		return 0, initialCursor, false
+	}
+	cursor++
+
+	if isKeyword(tokens, cursor, TransactionKeyword) || isKeyword(tokens, cursor, WorkKeyword) {
+		cursor++
+	}
+	return kind, cursor, true
+}
+
+// truncate [table] <表名>[, <表名> ...]
+func parseTruncateStatement(tokens []*Token, initialCursor uint) (*TruncateStatement, uint, bool, error) {
+	cursor := initialCursor
+	if !isKeyword(tokens, cursor, TruncateKeyword) {
+		return nil, initialCursor, false, nil
+	}
+	cursor++
+
+	if isKeyword(tokens, cursor, TableKeyword) {
+		cursor++
+	}
+
+	tables := []Token{}
+	for {
+		table, newCursor, err := expectIdentifier(tokens, cursor)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		tables = append(tables, *table)
+		cursor = newCursor
+
+		if !isSymbol(tokens, cursor, CommaSymbol) {
+			break
+		}
+		cursor++
+	}
+
+	start, end := span(tokens, initialCursor, cursor)
+	return &TruncateStatement{Tables: tables, Start: start, End: end}, cursor, true, nil
+}
+
+// update <表名> set <列名> = <表达式>[, <列名> = <表达式> ...] [where <表达式>]
+func parseUpdateStatement(tokens []*Token, depth *nesting, initialCursor uint) (*UpdateStatement, uint, bool, error) {
+	cursor := initialCursor
+	if !isKeyword(tokens, cursor, UpdateKeyword) {
+		return nil, initialCursor, false, nil
+	}
+	cursor++
+
+	table, cursor, err := expectIdentifier(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	cursor, err = expectKeyword(tokens, cursor, SetKeyword)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	set, cursor, err := parseAssignments(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	where, cursor, err := parseWhere(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	start, end := span(tokens, initialCursor, cursor)
+	return &UpdateStatement{
+		Table: *table,
+		Set:   set,
+		Where: where,
+		Start: start,
+		End:   end,
+	}, cursor, true, nil
+}
+
+// 逗号分隔的赋值，至少要有一个
+func parseAssignments(tokens []*Token, depth *nesting, initialCursor uint) ([]*Assignment, uint, error) {
+	cursor := initialCursor
+
+	set := []*Assignment{}
+	for {
+		if cursor >= uint(len(tokens)) || tokens[cursor].Kind != IdentifierKind {
+			if len(set) > 0 {
+				return nil, initialCursor, expected(tokens, cursor, "assignment after ','")
+			}
+			return nil, initialCursor, expected(tokens, cursor, "assignment after SET")
+		}
+		column := tokens[cursor]
+
+		newCursor, err := expectSymbol(tokens, cursor+1, EqSymbol)
+		if err != nil {
+			return nil, initialCursor, err
+		}
+
+		value, newCursor, ok, err := parseExpression(tokens, depth, newCursor)
+		if err != nil {
+			return nil, initialCursor, err
+		}
+		if !ok {
+			return nil, initialCursor, expected(tokens, newCursor, "expression")
+		}
+		cursor = newCursor
+		set = append(set, &Assignment{Column: *column, Value: value})
+
+		if !isSymbol(tokens, cursor, CommaSymbol) {
+			return set, cursor, nil
+		}
+		cursor++
+	}
+}
+
+// create table [if not exists] <表名> (<列名> <类型> [约束 ...][, ...][, 表级约束 ...])
+func parseCreateTableStatement(tokens []*Token, depth *nesting, initialCursor uint) (*CreateTableStatement, uint, bool, error) {
+	cursor := initialCursor
+	if !isKeyword(tokens, cursor, CreateKeyword) {
+		return nil, initialCursor, false, nil
+	}
+	cursor++
+
+	if !isKeyword(tokens, cursor, TableKeyword) {
+		return nil, initialCursor, false, expected(tokens, cursor, "TABLE, INDEX or VIEW")
+	}
+	cursor++
+
+	var err error
+
+	ifNotExists := false
+	if isKeyword(tokens, cursor, IfKeyword) {
+		cursor, err = expectKeyword(tokens, cursor+1, NotKeyword)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		cursor, err = expectKeyword(tokens, cursor, ExistsKeyword)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		ifNotExists = true
+	}
+
+	name, cursor, err := expectIdentifier(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	cursor, err = expectSymbol(tokens, cursor, LeftParenSymbol)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	crtTbl := CreateTableStatement{
+		Name:        *name,
+		IfNotExists: ifNotExists,
+	}
+	cursor, err = parseTableElements(tokens, depth, cursor, &crtTbl)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	crtTbl.Start, crtTbl.End = span(tokens, initialCursor, cursor)
+	return &crtTbl, cursor, true, nil
+}
+
+// create [unique] index <索引名> on <表名> (<列名>[, <列名> ...])
+func parseCreateIndexStatement(tokens []*Token, initialCursor uint) (*CreateIndexStatement, uint, bool, error) {
+	cursor := initialCursor
+	if !isKeyword(tokens, cursor, CreateKeyword) ||
+		(!isKeyword(tokens, cursor+1, IndexKeyword) && !isKeyword(tokens, cursor+1, UniqueKeyword)) {
+		return nil, initialCursor, false, nil
+	}
+	cursor++
+
+	crtIdx := CreateIndexStatement{}
+	if isKeyword(tokens, cursor, UniqueKeyword) {
+		crtIdx.Unique = true
+		cursor++
+	}
+
+	cursor, err := expectKeyword(tokens, cursor, IndexKeyword)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	name, cursor, err := expectIdentifier(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	crtIdx.Name = *name
+
+	cursor, err = expectKeyword(tokens, cursor, OnKeyword)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	table, cursor, err := expectIdentifier(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	crtIdx.Table = *table
+
+	open := cursor
+	cursor, err = expectSymbol(tokens, cursor, LeftParenSymbol)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	cols, cursor, err := parseColumnList(tokens, cursor, table)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	// 列名列表里每个 token 占一个位置，后面跟着逗号或右括号
+	for i, col := range cols {
+		for _, prev := range cols[:i] {
+			if prev.Value == col.Value {
+				return nil, initialCursor, false, errorAt(tokens, open+1+uint(2*i), fmt.Sprintf("index %s has duplicate column %s", name.Value, col.Value))
+			}
+		}
+	}
+	crtIdx.Cols = cols
+
+	crtIdx.Start, crtIdx.End = span(tokens, initialCursor, cursor)
+	return &crtIdx, cursor, true, nil
+}
+
+// 逗号分隔的列定义和表级约束 primary key (...)、unique (...)、
+// foreign key (...) references ...、check (...)，
+// 一直读到右括号（包括右括号），结果填进 crtTbl
+func parseTableElements(tokens []*Token, depth *nesting, initialCursor uint, crtTbl *CreateTableStatement) (uint, error) {
+	cursor := initialCursor
+
+	crtTbl.Cols = []*ColumnDefinition{}
+	// 一张表只能有一个主键，不管是写在列上还是写成表级约束
+	hasPrimaryKey := false
+	checkPrimaryKey := func(cursor uint) error {
+		if hasPrimaryKey {
+			return errorAt(tokens, cursor, fmt.Sprintf("table %s has more than one primary key", crtTbl.Name.Value))
+		}
+		hasPrimaryKey = true
+		return nil
+	}
+
+	for {
+		if isKeyword(tokens, cursor, PrimaryKeyword) {
+			if err := checkPrimaryKey(cursor); err != nil {
+				return initialCursor, err
+			}
+			newCursor, err := expectKeyword(tokens, cursor+1, KeyKeyword)
+			if err != nil {
+				return initialCursor, err
+			}
+			newCursor, err = expectSymbol(tokens, newCursor, LeftParenSymbol)
+			if err != nil {
+				return initialCursor, err
+			}
+			crtTbl.PrimaryKey, cursor, err = parseColumnList(tokens, newCursor, &crtTbl.Name)
+			if err != nil {
+				return initialCursor, err
+			}
+		} else if isKeyword(tokens, cursor, UniqueKeyword) {
+			newCursor, err := expectSymbol(tokens, cursor+1, LeftParenSymbol)
+			if err != nil {
+				return initialCursor, err
+			}
+			unique, newCursor, err := parseColumnList(tokens, newCursor, &crtTbl.Name)
+			if err != nil {
+				return initialCursor, err
+			}
+			cursor = newCursor
+			crtTbl.UniqueConstraints = append(crtTbl.UniqueConstraints, unique)
+		} else if isKeyword(tokens, cursor, ForeignKeyword) {
+			newCursor, err := expectKeyword(tokens, cursor+1, KeyKeyword)
+			if err != nil {
+				return initialCursor, err
+			}
+			newCursor, err = expectSymbol(tokens, newCursor, LeftParenSymbol)
+			if err != nil {
+				return initialCursor, err
+			}
+			cols, newCursor, err := parseColumnList(tokens, newCursor, &crtTbl.Name)
+			if err != nil {
+				return initialCursor, err
+			}
+			if !isKeyword(tokens, newCursor, ReferencesKeyword) {
+				return initialCursor, expected(tokens, newCursor, "REFERENCES")
+			}
+			fk, newCursor, err := parseReferences(tokens, newCursor, cols)
+			if err != nil {
+				return initialCursor, err
+			}
+			cursor = newCursor
+			crtTbl.ForeignKeys = append(crtTbl.ForeignKeys, fk)
+		} else if isKeyword(tokens, cursor, CheckKeyword) {
+			check, newCursor, err := parseCheck(tokens, depth, cursor)
+			if err != nil {
+				return initialCursor, err
+			}
+			cursor = newCursor
+			crtTbl.Checks = append(crtTbl.Checks, check)
+		} else {
+			cd, newCursor, err := parseColumnDefinition(tokens, depth, cursor, checkPrimaryKey)
+			if err != nil {
+				return initialCursor, err
+			}
+			cursor = newCursor
+			crtTbl.Cols = append(crtTbl.Cols, cd)
+		}
+
+		if isSymbol(tokens, cursor, RightParenSymbol) {
+			return cursor + 1, nil
+		}
+		if !isSymbol(tokens, cursor, CommaSymbol) {
+			return initialCursor, expected(tokens, cursor, "',' or ')'")
+		}
+		cursor++
+	}
+}
+
+// <列名> <类型> [约束 ...]，约束可以按任意顺序出现。
+// 每遇到一个 primary key 就调用一次 checkPrimaryKey，它返回的错误会直接报告。
+func parseColumnDefinition(tokens []*Token, depth *nesting, initialCursor uint, checkPrimaryKey func(uint) error) (*ColumnDefinition, uint, error) {
+	name, cursor, err := expectIdentifier(tokens, initialCursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+
+	datatype, cursor, err := expectDatatype(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	params, cursor, err := parseDatatypeParams(tokens, cursor, datatype)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	cd := ColumnDefinition{
+		Name:     *name,
+		Datatype: *datatype,
+		Params:   params,
+	}
+
+	for {
+		switch {
+		case isKeyword(tokens, cursor, PrimaryKeyword):
+			if err := checkPrimaryKey(cursor); err != nil {
+				return nil, initialCursor, err
+			}
+			cursor, err = expectKeyword(tokens, cursor+1, KeyKeyword)
+			if err != nil {
+				return nil, initialCursor, err
+			}
+			cd.PrimaryKey = true
+		case isKeyword(tokens, cursor, DefaultKeyword):
+			exp, newCursor, ok, err := parseExpression(tokens, depth, cursor+1)
+			if err != nil {
+				return nil, initialCursor, err
+			}
+			if !ok {
+				return nil, initialCursor, expected(tokens, cursor+1, "expression after DEFAULT")
+			}
+			cursor = newCursor
+			cd.Default = exp
+		case isKeyword(tokens, cursor, CheckKeyword):
+			check, newCursor, err := parseCheck(tokens, depth, cursor)
+			if err != nil {
+				return nil, initialCursor, err
+			}
+			cursor = newCursor
+			cd.Checks = append(cd.Checks, check)
+		case isKeyword(tokens, cursor, ReferencesKeyword):
+			fk, newCursor, err := parseReferences(tokens, cursor, []Token{cd.Name})
+			if err != nil {
+				return nil, initialCursor, err
+			}
+			cursor = newCursor
+			cd.References = fk
+		case isKeyword(tokens, cursor, UniqueKeyword):
+			cursor++
+			cd.Unique = true
+		case isKeyword(tokens, cursor, NotKeyword):
+			cursor, err = expectKeyword(tokens, cursor+1, NullKeyword)
+			if err != nil {
+				return nil, initialCursor, err
+			}
+			cd.NotNull = true
+		case isKeyword(tokens, cursor, NullKeyword):
+			// 单独的 null 只是显式声明列可以为空，和默认一样
+			cursor++
+		default:
+			return &cd, cursor, nil
+		}
+	}
+}
+
+// references <表名> [(<列名>[, ...])]，cols 是引用方的列。
+// 被引用的列可以省略，这时引用的是被引用表的主键。
+func parseReferences(tokens []*Token, initialCursor uint, cols []Token) (*ForeignKey, uint, error) {
+	cursor := initialCursor + 1
+	if cursor >= uint(len(tokens)) || tokens[cursor].Kind != IdentifierKind {
+		return nil, initialCursor, expected(tokens, cursor, "table name after REFERENCES")
+	}
+	fk := ForeignKey{
+		Cols:  cols,
+		Table: *tokens[cursor],
+	}
+	cursor++
+
+	if isSymbol(tokens, cursor, LeftParenSymbol) {
+		refCols, newCursor, err := parseColumnList(tokens, cursor+1, &fk.Table)
+		if err != nil {
+			return nil, initialCursor, err
+		}
+		cursor = newCursor
+		fk.RefCols = refCols
+	}
+
+	return &fk, cursor, nil
+}
+
+// check (<表达式>)，列约束和表级约束的写法一样。
+// 括号交给 parsePrimaryExpression 处理，括号不配对时报告的是它的错误。
+func parseCheck(tokens []*Token, depth *nesting, initialCursor uint) (*Expression, uint, error) {
+	cursor := initialCursor + 1
+	if !isSymbol(tokens, cursor, LeftParenSymbol) {
+		return nil, initialCursor, expected(tokens, cursor, "'(' after CHECK")
+	}
+
+	exp, cursor, ok, err := parsePrimaryExpression(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if !ok {
+		return nil, initialCursor, expected(tokens, initialCursor+2, "expression")
+	}
+
+	return exp, cursor, nil
+}
+
+// 逗号分隔的表达式，至少要有一个
+func parseExpressions(tokens []*Token, depth *nesting, initialCursor uint) ([]*Expression, uint, error) {
+	cursor := initialCursor
+
+	exps := []*Expression{}
+	for {
+		exp, newCursor, ok, err := parseExpression(tokens, depth, cursor)
+		if err != nil {
+			return nil, initialCursor, err
+		}
+		if !ok {
+			return nil, initialCursor, expected(tokens, cursor, "expression")
+		}
+		cursor = newCursor
+		exps = append(exps, exp)
+
+		if !isSymbol(tokens, cursor, CommaSymbol) {
+			return exps, cursor, nil
+		}
+		cursor++
+	}
+}
+
+// 运算符的优先级，数字越大结合越紧：
+// OR < AND < NOT < 比较 < 加减 < 乘除 < 一元正负 < ||
+const (
+	orPrecedence uint = iota + 1
+	andPrecedence
+	notPrecedence
+	comparisonPrecedence
+	additivePrecedence
+	multiplicativePrecedence
+	unaryPrecedence
+	concatPrecedence
+)
+
+// binaryPrecedence 返回二元运算符的优先级，不是二元运算符时返回 0
+func binaryPrecedence(tok *Token) uint {
+	switch tok.Kind {
+	case KeywordKind:
+		switch Keyword(tok.Value) {
+		case OrKeyword:
+			return orPrecedence
+		case AndKeyword:
+			return andPrecedence
+		}
+	case OperatorKind:
+		switch Symbol(tok.Value) {
+		case EqSymbol, NeqSymbol, LtSymbol, GtSymbol, LteSymbol, GteSymbol:
+			return comparisonPrecedence
+		case PlusSymbol, MinusSymbol:
+			return additivePrecedence
+		case AsteriskSymbol, SlashSymbol, ModuloSymbol:
+			return multiplicativePrecedence
+		case ConcatSymbol:
+			return concatPrecedence
+		}
+	}
+	return 0
+}
+
+func parseExpression(tokens []*Token, depth *nesting, initialCursor uint) (*Expression, uint, bool, error) {
+	return parseBinaryExpression(tokens, depth, initialCursor, 0)
+}
+
+// 用优先级爬升法解析二元表达式，只消费优先级高于 minPrecedence 的运算符。
+// 同级运算符左结合：1 - 2 - 3 是 (1 - 2) - 3。
+func parseBinaryExpression(tokens []*Token, depth *nesting, initialCursor uint, minPrecedence uint) (*Expression, uint, bool, error) {
+	exp, cursor, ok, err := parseUnaryExpression(tokens, depth, initialCursor)
+	if err != nil || !ok {
+		return nil, initialCursor, ok, err
+	}
+
+	for cursor < uint(len(tokens)) {
+		// in 这样的谓词和比较运算符同级，但右边不是一个普通的表达式
+		if comparisonPrecedence > minPrecedence {
+			predicate, newCursor, ok, err := parsePredicate(tokens, depth, exp, cursor)
+			if err != nil {
+				return nil, initialCursor, false, err
+			}
+			if ok {
+				exp = predicate
+				cursor = newCursor
+				exp.Start, exp.End = span(tokens, initialCursor, cursor)
+				continue
+			}
+		}
+
+		op := tokens[cursor]
+		precedence := binaryPrecedence(op)
+		if precedence == 0 || precedence <= minPrecedence {
+			break
+		}
+
+		right, newCursor, ok, err := parseBinaryExpression(tokens, depth, cursor+1, precedence)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		if !ok {
+			return nil, initialCursor, false, expected(tokens, cursor+1, "expression")
+		}
+		cursor = newCursor
+
+		exp = &Expression{
+			Binary: &BinaryExpression{
+				A:  exp,
+				B:  right,
+				Op: *op,
+			},
+			Kind: BinaryKind,
+		}
+		exp.Start, exp.End = span(tokens, initialCursor, cursor)
+	}
+
+	return exp, cursor, true, nil
+}
+
+// parsePredicate 解析跟在 left 后面的 [not] in (...)、[not] between ... and ...、
+// [not] like ... [escape ...] 或 is [not] null，不是谓词时返回 false
+func parsePredicate(tokens []*Token, depth *nesting, left *Expression, initialCursor uint) (*Expression, uint, bool, error) {
+	cursor := initialCursor
+	if isKeyword(tokens, cursor, IsKeyword) {
+		isNull, cursor, err := parseIsNull(tokens, cursor+1)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		isNull.Exp = left
+
+		return &Expression{
+			IsNull: isNull,
+			Kind:   IsNullKind,
+		}, cursor, true, nil
+	}
+
+	not := false
+	if isKeyword(tokens, cursor, NotKeyword) {
+		not = true
+		cursor++
+	}
+
+	if isKeyword(tokens, cursor, BetweenKeyword) {
+		between, cursor, err := parseBetween(tokens, depth, cursor+1)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		between.Exp = left
+		between.Not = not
+
+		return &Expression{
+			Between: between,
+			Kind:    BetweenKind,
+		}, cursor, true, nil
+	}
+
+	if isKeyword(tokens, cursor, LikeKeyword) {
+		like, cursor, err := parseLike(tokens, depth, cursor+1)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		like.Exp = left
+		like.Not = not
+
+		return &Expression{
+			Like: like,
+			Kind: LikeKind,
+		}, cursor, true, nil
+	}
+
+	if !isKeyword(tokens, cursor, InKeyword) {
+		return nil, initialCursor, false, nil
+	}
+	cursor++
+
+	in, cursor, err := parseInList(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	in.Left = left
+	in.Not = not
+
+	return &Expression{
+		In:   in,
+		Kind: InKind,
+	}, cursor, true, nil
+}
+
+// between 后面的 Low and High。两端只解析比比较运算符优先级高的部分，
+// 所以中间的 and 属于 between，而不会被当成逻辑与：
+// a between 1 and 2 and b 是 (a between 1 and 2) and b
+func parseBetween(tokens []*Token, depth *nesting, initialCursor uint) (*BetweenExpression, uint, error) {
+	low, cursor, ok, err := parseBinaryExpression(tokens, depth, initialCursor, comparisonPrecedence)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if !ok {
+		return nil, initialCursor, expected(tokens, initialCursor, "expression")
+	}
+
+	cursor, err = expectKeyword(tokens, cursor, AndKeyword)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+
+	high, newCursor, ok, err := parseBinaryExpression(tokens, depth, cursor, comparisonPrecedence)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if !ok {
+		return nil, initialCursor, expected(tokens, cursor, "expression")
+	}
+
+	return &BetweenExpression{Low: low, High: high}, newCursor, nil
+}
+
+// like 后面的模式和可选的 escape，和比较运算符的右操作数一样只解析优先级更高的部分
+func parseLike(tokens []*Token, depth *nesting, initialCursor uint) (*LikeExpression, uint, error) {
+	pattern, cursor, ok, err := parseBinaryExpression(tokens, depth, initialCursor, comparisonPrecedence)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+	if !ok {
+		return nil, initialCursor, expected(tokens, initialCursor, "expression")
+	}
+
+	like := LikeExpression{Pattern: pattern}
+	if isKeyword(tokens, cursor, EscapeKeyword) {
+		escape, newCursor, ok, err := parseBinaryExpression(tokens, depth, cursor+1, comparisonPrecedence)
+		if err != nil {
+			return nil, initialCursor, err
+		}
+		if !ok {
+			return nil, initialCursor, expected(tokens, cursor+1, "expression")
+		}
+		like.Escape = escape
+		cursor = newCursor
+	}
+
+	return &like, cursor, nil
+}
+
+// is 后面只能是 null 或 not null，is true 这样的写法还不支持
+func parseIsNull(tokens []*Token, initialCursor uint) (*IsNullExpression, uint, error) {
+	cursor := initialCursor
+	not := false
+	if isKeyword(tokens, cursor, NotKeyword) {
+		not = true
+		cursor++
+	}
+
+	if !isKeyword(tokens, cursor, NullKeyword) {
+		if not {
+			return nil, initialCursor, expected(tokens, cursor, "NULL")
+		}
+		return nil, initialCursor, expected(tokens, cursor, "NULL or NOT NULL")
+	}
+
+	return &IsNullExpression{Not: not}, cursor + 1, nil
+}
+
+// in 后面括号里的值列表或子查询，看左括号后面是不是 select 来区分
+func parseInList(tokens []*Token, depth *nesting, initialCursor uint) (*InExpression, uint, error) {
+	cursor, err := expectSymbol(tokens, initialCursor, LeftParenSymbol)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+
+	if isKeyword(tokens, cursor, SelectKeyword) {
+		slct, cursor, err := parseSubquery(tokens, depth, initialCursor)
+		if err != nil {
+			return nil, initialCursor, err
+		}
+		return &InExpression{Select: slct}, cursor, nil
+	}
+
+	if isSymbol(tokens, cursor, RightParenSymbol) {
+		return nil, initialCursor, errorAt(tokens, cursor, "IN list must not be empty")
+	}
+	if err := depth.enter(tokens, initialCursor); err != nil {
+		return nil, initialCursor, err
+	}
+	defer depth.leave()
+
+	list, cursor, err := parseExpressions(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, err
+	}
+
+	if !isSymbol(tokens, cursor, RightParenSymbol) {
+		open := tokens[initialCursor].Loc
+		return nil, initialCursor, expected(tokens, cursor, fmt.Sprintf("')' to close '(' from line %d col %d", open.Line+1, open.Col+1))
+	}
+
+	return &InExpression{List: list}, cursor + 1, nil
+}
+
+// 前缀的 not、+ 和 -。数字前面的 + 或 - 通过 FoldSign 折叠进字面量，
+// 其他情况下产生一元表达式。
+func parseUnaryExpression(tokens []*Token, depth *nesting, initialCursor uint) (*Expression, uint, bool, error) {
+	cursor := initialCursor
+	if cursor >= uint(len(tokens)) {
+		return nil, initialCursor, false, nil
+	}
+
+	tok := tokens[cursor]
+	precedence := uint(0)
+	switch {
+	case isKeyword(tokens, cursor, NotKeyword):
+		precedence = notPrecedence
+	case isSymbol(tokens, cursor, PlusSymbol), isSymbol(tokens, cursor, MinusSymbol):
+		if cursor+1 < uint(len(tokens)) {
+			if folded, ok := FoldSign(tok, tokens[cursor+1]); ok {
+				exp := &Expression{
+					Literal: folded,
+					Kind:    LiteralKind,
+				}
+				exp.Start, exp.End = span(tokens, cursor, cursor+2)
+				return parseCasts(tokens, exp, cursor+2)
+			}
+		}
+		precedence = unaryPrecedence
+	default:
+		return parsePostfixExpression(tokens, depth, initialCursor)
+	}
+
+	// 操作数递归解析，每个前缀运算符都深一层
+	if err := depth.enter(tokens, initialCursor); err != nil {
+		return nil, initialCursor, false, err
+	}
+	defer depth.leave()
+
+	operand, cursor, ok, err := parseBinaryExpression(tokens, depth, cursor+1, precedence)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	if !ok {
+		return nil, initialCursor, false, expected(tokens, initialCursor+1, "expression")
+	}
+
+	start, end := span(tokens, initialCursor, cursor)
+	return &Expression{
+		Unary: &UnaryExpression{
+			Op:      *tok,
+			Operand: operand,
+		},
+		Kind:  UnaryKind,
+		Start: start,
+		End:   end,
+	}, cursor, true, nil
+}
+
+// 后缀的 :: 类型转换比所有运算符结合得都紧，可以连写：x::text::int
+func parsePostfixExpression(tokens []*Token, depth *nesting, initialCursor uint) (*Expression, uint, bool, error) {
+	exp, cursor, ok, err := parsePrimaryExpression(tokens, depth, initialCursor)
+	if err != nil || !ok {
+		return nil, initialCursor, ok, err
+	}
+
+	exp, cursor, _, err = parseCasts(tokens, exp, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	return exp, cursor, true, nil
+}
+
+// parseCasts 把 exp 后面的每个 ::<类型> 从左到右套在 exp 外面
+func parseCasts(tokens []*Token, exp *Expression, initialCursor uint) (*Expression, uint, bool, error) {
+	cursor := initialCursor
+	for isSymbol(tokens, cursor, CastSymbol) {
+		datatype, newCursor, err := expectDatatype(tokens, cursor+1)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		cursor = newCursor
+
+		exp = &Expression{
+			Cast:  &CastExpression{Exp: exp, Type: *datatype},
+			Kind:  CastKind,
+			Start: exp.Start,
+			End:   tokens[cursor-1].End,
+		}
+	}
+	return exp, cursor, true, nil
+}
+
+// cast(<表达式> as <类型>)
+func parseCastExpression(tokens []*Token, depth *nesting, initialCursor uint) (*Expression, uint, bool, error) {
+	cursor, err := expectSymbol(tokens, initialCursor+1, LeftParenSymbol)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	if err := depth.enter(tokens, initialCursor+1); err != nil {
+		return nil, initialCursor, false, err
+	}
+	defer depth.leave()
+
+	exp, cursor, ok, err := parseExpression(tokens, depth, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	if !ok {
+		return nil, initialCursor, false, expected(tokens, cursor, "expression")
+	}
+
+	cursor, err = expectKeyword(tokens, cursor, AsKeyword)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	datatype, cursor, err := expectDatatype(tokens, cursor)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	if !isSymbol(tokens, cursor, RightParenSymbol) {
+		open := tokens[initialCursor+1].Loc
+		return nil, initialCursor, false, expected(tokens, cursor, fmt.Sprintf("')' to close '(' from line %d col %d", open.Line+1, open.Col+1))
+	}
+
+	return &Expression{
+		Cast: &CastExpression{Exp: exp, Type: *datatype},
+		Kind: CastKind,
+	}, cursor + 1, true, nil
+}
+
+// 括号只改变结合顺序，不在语法树中留下节点，((1)) 就是字面量 1，
+// 它的位置也不包括括号
+func parsePrimaryExpression(tokens []*Token, depth *nesting, initialCursor uint) (*Expression, uint, bool, error) {
+	if !isSymbol(tokens, initialCursor, LeftParenSymbol) {
+		exp, cursor, ok, err := parseAtomExpression(tokens, depth, initialCursor)
+		if err != nil || !ok {
+			return nil, initialCursor, ok, err
+		}
+		exp.Start, exp.End = span(tokens, initialCursor, cursor)
+		return exp, cursor, true, nil
+	}
+
+	if err := depth.enter(tokens, initialCursor); err != nil {
+		return nil, initialCursor, false, err
+	}
+	defer depth.leave()
+
+	exp, cursor, ok, err := parseExpression(tokens, depth, initialCursor+1)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	if !ok {
+		return nil, initialCursor, false, expected(tokens, initialCursor+1, "expression")
+	}
+
+	if !isSymbol(tokens, cursor, RightParenSymbol) {
+		open := tokens[initialCursor].Loc
+		return nil, initialCursor, false, expected(tokens, cursor, fmt.Sprintf("')' to close '(' from line %d col %d", open.Line+1, open.Col+1))
+	}
+
+	return exp, cursor + 1, true, nil
+}
+
+// 不以括号开头的基本表达式：case、cast、exists、带类型的字面量、
+// 函数调用、列名和字面量
+func parseAtomExpression(tokens []*Token, depth *nesting, initialCursor uint) (*Expression, uint, bool, error) {
+	if isKeyword(tokens, initialCursor, CaseKeyword) {
+		return parseCaseExpression(tokens, depth, initialCursor)
+	}
+	if isKeyword(tokens, initialCursor, CastKeyword) {
+		return parseCastExpression(tokens, depth, initialCursor)
+	}
+	if isKeyword(tokens, initialCursor, ExistsKeyword) {
+		return parseExistsExpression(tokens, depth, initialCursor)
+	}
+	for k := range temporalLayouts {
+		if isKeyword(tokens, initialCursor, k) {
+			return parseTypedLiteral(tokens, initialCursor)
+		}
+	}
+	// 标识符后面紧跟左括号就是函数调用，中间可以有空白
+	if initialCursor < uint(len(tokens)) && tokens[initialCursor].Kind == IdentifierKind &&
+		isSymbol(tokens, initialCursor+1, LeftParenSymbol) {
+		return parseFunctionCall(tokens, depth, initialCursor)
+	}
+	// 其他标识符都是列名
+	if initialCursor < uint(len(tokens)) && tokens[initialCursor].Kind == IdentifierKind {
+		return parseColumnReference(tokens, initialCursor)
+	}
+	return parseLiteralExpression(tokens, initialCursor)
+}
+
+// [<表名或别名>.]<列名>
+func parseColumnReference(tokens []*Token, initialCursor uint) (*Expression, uint, bool, error) {
+	if !isSymbol(tokens, initialCursor+1, DotSymbol) {
+		return &Expression{
+			Column: &ColumnReference{Name: *tokens[initialCursor]},
+			Kind:   ColumnKind,
+		}, initialCursor + 1, true, nil
+	}
+
+	name, cursor, err := expectIdentifier(tokens, initialCursor+2)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	return &Expression{
+		Column: &ColumnReference{Qualifier: tokens[initialCursor], Name: *name},
+		Kind:   ColumnKind,
+	}, cursor, true, nil
+}
+
+// 时间类型字面量的格式，time.Parse 解析时秒后面可以带小数
+var temporalLayouts = map[Keyword]string{
+	DateKeyword:      "2006-01-02",
+	TimeKeyword:      "15:04:05",
+	TimestampKeyword: "2006-01-02 15:04:05",
+}
+
+// 出错时提示给人看的格式
+var temporalFormats = map[Keyword]string{
+	DateKeyword:      "YYYY-MM-DD",
+	TimeKeyword:      "HH:MM:SS[.ffffff]",
+	TimestampKeyword: "YYYY-MM-DD HH:MM:SS[.ffffff]",
+}
+
+// 暂不支持时区，带这些后缀的 time 和 timestamp 单独报错
+var timeZoneSuffixes = []string{"Z07:00", "Z0700", "Z07", " Z07:00", " MST"}
+
+// <date|time|timestamp> '<字符串>'，字符串的格式在解析时检查
+func parseTypedLiteral(tokens []*Token, initialCursor uint) (*Expression, uint, bool, error) {
+	typ := tokens[initialCursor]
+	cursor := initialCursor + 1
+	if cursor >= uint(len(tokens)) || tokens[cursor].Kind != StringKind {
+		return nil, initialCursor, false, expected(tokens, cursor, "string literal")
+	}
+	value := tokens[cursor]
+
+	name := strings.ToUpper(typ.Value)
+	layout := temporalLayouts[Keyword(typ.Value)]
+	if _, err := time.Parse(layout, value.Value); err != nil {
+		if typ.Value != string(DateKeyword) {
+			for _, suffix := range timeZoneSuffixes {
+				if _, err := time.Parse(layout+suffix, value.Value); err == nil {
+					return nil, initialCursor, false, errorAt(tokens, cursor, fmt.Sprintf("time zone in %s literal '%s' is not supported", name, value.Value))
+				}
+			}
+		}
+		return nil, initialCursor, false, errorAt(tokens, cursor, fmt.Sprintf("invalid %s literal '%s', expected format %s", name, value.Value, temporalFormats[Keyword(typ.Value)]))
+	}
+
+	return &Expression{
+		TypedLiteral: &TypedLiteralExpression{Type: *typ, Value: *value},
+		Kind:         TypedLiteralKind,
+	}, cursor + 1, true, nil
+}
+
+// exists (<select>)，括号不能省略
+func parseExistsExpression(tokens []*Token, depth *nesting, initialCursor uint) (*Expression, uint, bool, error) {
+	cursor, err := expectSymbol(tokens, initialCursor+1, LeftParenSymbol)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+	if !isKeyword(tokens, cursor, SelectKeyword) {
+		return nil, initialCursor, false, expected(tokens, cursor, "SELECT")
+	}
+
+	slct, cursor, err := parseSubquery(tokens, depth, initialCursor+1)
+	if err != nil {
+		return nil, initialCursor, false, err
+	}
+
+	return &Expression{
+		Exists: &ExistsExpression{Select: slct},
+		Kind:   ExistsKind,
+	}, cursor, true, nil
+}
+
+// case [<表达式>] when <表达式> then <表达式> [when ...] [else <表达式>] end。
+// 缺少 when 或 end 时错误指向 case。
+func parseCaseExpression(tokens []*Token, depth *nesting, initialCursor uint) (*Expression, uint, bool, error) {
+	if err := depth.enter(tokens, initialCursor); err != nil {
+		return nil, initialCursor, false, err
+	}
+	defer depth.leave()
+
+	cursor := initialCursor + 1
+
+	c := CaseExpression{}
+	if !isKeyword(tokens, cursor, WhenKeyword) {
+		operand, newCursor, ok, err := parseExpression(tokens, depth, cursor)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		if ok {
+			c.Operand = operand
+			cursor = newCursor
+		}
+	}
+
+	for isKeyword(tokens, cursor, WhenKeyword) {
+		when, newCursor, ok, err := parseExpression(tokens, depth, cursor+1)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		if !ok {
+			return nil, initialCursor, false, expected(tokens, cursor+1, "expression")
+		}
+		cursor = newCursor
+
+		cursor, err = expectKeyword(tokens, cursor, ThenKeyword)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+
+		then, newCursor, ok, err := parseExpression(tokens, depth, cursor)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		if !ok {
+			return nil, initialCursor, false, expected(tokens, cursor, "expression")
+		}
+		cursor = newCursor
+
+		c.Whens = append(c.Whens, &WhenClause{When: when, Then: then})
+	}
+	if len(c.Whens) == 0 {
+		return nil, initialCursor, false, errorAt(tokens, initialCursor, "CASE needs at least one WHEN")
+	}
+
+	if isKeyword(tokens, cursor, ElseKeyword) {
+		els, newCursor, ok, err := parseExpression(tokens, depth, cursor+1)
+		if err != nil {
+			return nil, initialCursor, false, err
+		}
+		if !ok {
+			return nil, initialCursor, false, expected(tokens, cursor+1, "expression")
+		}
+		c.Else = els
+		cursor = newCursor
+	}
+
+	if !isKeyword(tokens, cursor, EndKeyword) {
+		return nil, initialCursor, false, errorAt(tokens, initialCursor, "CASE is missing END")
+	}
+
+	return &Expression{
+		Case: &c,
+		Kind: CaseKind,
+	}, cursor + 1, true, nil
+}
+
+// <函数名>([distinct] [<表达式>[, <表达式> ...]])，count 还可以写成 count(*)。
+// 解析器不关心函数是否存在，参数个数也留给之后的语义检查。
+func parseFunctionCall(tokens []*Token, depth *nesting, initialCursor uint) (*Expression, uint, bool, error) {
+	name := tokens[initialCursor]
+	open := tokens[initialCursor+1].Loc
+	cursor := initialCursor + 2
+	if err := depth.enter(tokens, initialCursor+1); err != nil {
+		return nil, initialCursor, false, err
+	}
+	defer depth.leave()
+
+	call := FunctionCallExpression{Name: *name, Args: []*Expression{}}
+	if isSymbol(tokens, cursor, AsteriskSymbol) && strings.ToLower(name.Value) == "count" {
+		call.Asterisk = true
+		cursor++
+	} else {
+		if isKeyword(tokens, cursor, DistinctKeyword) {
+			call.Distinct = true
+			cursor++
+		}
+
+		// 没有参数时右括号直接跟在左括号后面，但 distinct 后面必须有参数
+		if call.Distinct || !isSymbol(tokens, cursor, RightParenSymbol) {
+			args, newCursor, err := parseExpressions(tokens, depth, cursor)
+			if err != nil {
+				return nil, initialCursor, false, err
+			}
+			call.Args = args
+			cursor = newCursor
+		}
+	}
+
+	if !isSymbol(tokens, cursor, RightParenSymbol) {
+		return nil, initialCursor, false, expected(tokens, cursor, fmt.Sprintf("')' to close '(' from line %d col %d", open.Line+1, open.Col+1))
+	}
+
+	return &Expression{
+		Call: &call,
+		Kind: FunctionCallKind,
+	}, cursor + 1, true, nil
+}
+
+// 字面量：数字、字符串、布尔值、参数和 null
+func parseLiteralExpression(tokens []*Token, initialCursor uint) (*Expression, uint, bool, error) {
+	cursor := initialCursor
+	if cursor >= uint(len(tokens)) {
+		return nil, initialCursor, false, nil
+	}
+
+	tok := tokens[cursor]
+	switch tok.Kind {
+	case NumericKind, StringKind, BoolKind, ParameterKind, NamedParameterKind:
+	case KeywordKind:
+		if tok.Value != string(NullKeyword) {
+			return nil, initialCursor, false, nil
+		}
+	default:
+		return nil, initialCursor, false, nil
+	}
+
+	return &Expression{
+		Literal: tok,
+		Kind:    LiteralKind,
+	}, cursor + 1, true, nil
+}
+
+func isKeyword(tokens []*Token, cursor uint, k Keyword) bool {
+	return cursor < uint(len(tokens)) && tokens[cursor].Kind == KeywordKind && tokens[cursor].Value == string(k)
+}
+
+// span 返回 tokens[start:end] 的起止位置，也就是从 start 处的 token 开始
+// 到 end 前面的 token 结束，end 是节点之后的游标
+func span(tokens []*Token, start, end uint) (Location, Location) {
+	return tokens[start].Loc, tokens[end-1].End
+}
+
+// 符号和运算符都按值比较，调用方不需要关心 token 是哪一种
+func isSymbol(tokens []*Token, cursor uint, s Symbol) bool {
+	if cursor >= uint(len(tokens)) {
+		return false
+	}
+	tok := tokens[cursor]
+	return (tok.Kind == SymbolKind || tok.Kind == OperatorKind) && tok.Value == string(s)
+}
+
+func expectKeyword(tokens []*Token, cursor uint, k Keyword) (uint, error) {
+	if !isKeyword(tokens, cursor, k) {
+		return cursor, expected(tokens, cursor, strings.ToUpper(string(k)))
+	}
+	return cursor + 1, nil
+}
+
+func expectSymbol(tokens []*Token, cursor uint, s Symbol) (uint, error) {
+	if !isSymbol(tokens, cursor, s) {
+		return cursor, expected(tokens, cursor, fmt.Sprintf("'%s'", s))
+	}
+	return cursor + 1, nil
+}
+
+// 列定义和类型转换都能用的类型
+var datatypes = []Keyword{
+	IntKeyword,
+	TextKeyword,
+	BooleanKeyword,
+	RealKeyword,
+	FloatKeyword,
+	NumericKeyword,
+	DecimalKeyword,
+	VarcharKeyword,
+	CharKeyword,
+	BigintKeyword,
+	DateKeyword,
+	TimeKeyword,
+	TimestampKeyword,
+}
+
+// 类型后面括号里最多能有几个参数，不在表里的类型不接受参数
+var datatypeParams = map[Keyword]int{
+	FloatKeyword:   1,
+	NumericKeyword: 2,
+	DecimalKeyword: 2,
+	VarcharKeyword: 1,
+	CharKeyword:    1,
+}
+
+// [(<参数> [, <参数>])]，参数都是非负整数，个数不能超过 datatypeParams 中的上限
+func parseDatatypeParams(tokens []*Token, initialCursor uint, datatype *Token) ([]Token, uint, error) {
+	if !isSymbol(tokens, initialCursor, LeftParenSymbol) {
+		return nil, initialCursor, nil
+	}
+
+	name := strings.ToUpper(datatype.Value)
+	max := datatypeParams[Keyword(datatype.Value)]
+	if max == 0 {
+		return nil, initialCursor, errorAt(tokens, initialCursor, name+" does not take parameters")
+	}
+
+	cursor := initialCursor + 1
+	var params []Token
+	for {
+		if len(params) == max {
+			return nil, initialCursor, errorAt(tokens, cursor, fmt.Sprintf("too many parameters for %s, at most %d", name, max))
+		}
+		if cursor >= uint(len(tokens)) || tokens[cursor].Kind != NumericKind {
+			return nil, initialCursor, expected(tokens, cursor, "non-negative integer")
+		}
+		if _, err := strconv.ParseUint(tokens[cursor].Value, 10, 32); err != nil {
+			return nil, initialCursor, errorAt(tokens, cursor, fmt.Sprintf("parameter %s of %s is not a non-negative integer", tokens[cursor].Value, name))
+		}
+		params = append(params, *tokens[cursor])
+		cursor++
+
+		if isSymbol(tokens, cursor, RightParenSymbol) {
+			return params, cursor + 1, nil
+		}
+		if !isSymbol(tokens, cursor, CommaSymbol) {
+			return nil, initialCursor, expected(tokens, cursor, "',' or ')'")
+		}
+		cursor++
+	}
+}
+
+func expectDatatype(tokens []*Token, cursor uint) (*Token, uint, error) {
+	names := make([]string, len(datatypes))
+	for i, k := range datatypes {
+		if isKeyword(tokens, cursor, k) {
+			return tokens[cursor], cursor + 1, nil
+		}
+		names[i] = strings.ToUpper(string(k))
+	}
+	if len(names) == 1 {
+		return nil, cursor, expected(tokens, cursor, names[0])
+	}
+	return nil, cursor, expected(tokens, cursor, strings.Join(names[:len(names)-1], ", ")+" or "+names[len(names)-1])
+}
+
+func expectIdentifier(tokens []*Token, cursor uint) (*Token, uint, error) {
+	if cursor >= uint(len(tokens)) || tokens[cursor].Kind != IdentifierKind {
+		return nil, cursor, expected(tokens, cursor, "identifier")
+	}
+	return tokens[cursor], cursor + 1, nil
+}
+
+// ParseError 是语法分析失败时返回的错误。
+// Found 是实际遇到的 token，输入提前结束时为 nil，此时 Loc 是输入结束的位置。
+// Expected 是给人看的期望描述，比如 FROM or ';'。
+// 语法正确但语义不对的错误（比如值的个数和列数不一致）没有 Expected，
+// 而是用 Msg 描述问题。
+type ParseError struct {
+	Loc      Location
+	Found    *Token
+	Expected string
+	Msg      string
+}
+
+func (e *ParseError) Error() string {
+	if e.Msg != "" {
+		return fmt.Sprintf("%s at line %d col %d", e.Msg, e.Loc.Line+1, e.Loc.Col+1)
+	}
+
+	found := "end of input"
+	if e.Found != nil {
+		found = fmt.Sprintf("%s %q", e.Found.Kind, e.Found.Value)
+	}
+	return fmt.Sprintf("expected %s but found %s at line %d col %d", e.Expected, found, e.Loc.Line+1, e.Loc.Col+1)
+}
+
+// expected 返回在 cursor 处期望 what 却没有找到的 ParseError，
+// 所有产生式都通过它报告错误，保证格式一致。
+func expected(tokens []*Token, cursor uint, what string) error {
+	if cursor < uint(len(tokens)) {
+		return &ParseError{
+			Loc:      tokens[cursor].Loc,
+			Found:    tokens[cursor],
+			Expected: what,
+		}
+	}
+
+	loc := Location{}
+	if len(tokens) > 0 {
+		loc = tokens[len(tokens)-1].End
+	}
+	return &ParseError{
+		Loc:      loc,
+		Expected: what,
+	}
+}
+
+// errorAt 返回位于 cursor 处 token 的 ParseError，msg 描述出了什么问题
+func errorAt(tokens []*Token, cursor uint, msg string) error {
+	err := expected(tokens, cursor, "").(*ParseError)
+	err.Msg = msg
+	return err
+}
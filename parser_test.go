@@ -0,0 +1,3076 @@
+package gosql
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tok 构造单行源码中从 col 开始的 token，字符串的 Raw 带上引号
+func tok(value string, kind TokenKind, col uint) *Token {
+	raw := value
+	if kind == StringKind {
+		raw = "'" + value + "'"
+	}
+	end := col + uint(len(raw))
+	return &Token{
+		Value:  value,
+		Raw:    raw,
+		Kind:   kind,
+		Loc:    Location{Col: col},
+		End:    Location{Col: end},
+		Pos:    col,
+		EndPos: end,
+	}
+}
+
+func literal(value string, kind TokenKind, col uint) *Expression {
+	return &Expression{
+		Literal: tok(value, kind, col),
+		Kind:    LiteralKind,
+	}
+}
+
+// columnRef 构造不带限定的列名
+func columnRef(name string, col uint) *Expression {
+	return &Expression{
+		Column: &ColumnReference{Name: *tok(name, IdentifierKind, col)},
+		Kind:   ColumnKind,
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		source string
+		ast    *Ast
+	}{
+		{
+			source: "select a from t;",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: SelectKind,
+						SelectStatement: &SelectStatement{
+							Item: []*SelectItem{
+								{Exp: columnRef("a", 7)},
+							},
+							From: &TableReference{Name: tok("t", IdentifierKind, 14)},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "select 1, 'x', -2, true, null, $1 from t;",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: SelectKind,
+						SelectStatement: &SelectStatement{
+							Item: []*SelectItem{
+								{Exp: &Expression{Literal: tok("1", NumericKind, 7), Kind: LiteralKind}},
+								{Exp: &Expression{Literal: tok("x", StringKind, 10), Kind: LiteralKind}},
+								{Exp: &Expression{Literal: tok("-2", NumericKind, 15), Kind: LiteralKind}},
+								{Exp: &Expression{Literal: tok("true", BoolKind, 19), Kind: LiteralKind}},
+								{Exp: &Expression{Literal: tok("null", KeywordKind, 25), Kind: LiteralKind}},
+								{Exp: &Expression{Literal: tok("$1", ParameterKind, 31), Kind: LiteralKind}},
+							},
+							From: &TableReference{Name: tok("t", IdentifierKind, 39)},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "insert into users values (105, 'Ada');",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: InsertKind,
+						InsertStatement: &InsertStatement{
+							Table: *tok("users", IdentifierKind, 12),
+							Values: [][]*Expression{{
+								literal("105", NumericKind, 26),
+								literal("Ada", StringKind, 31),
+							}},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "insert into users (id, name) values (1, 'Ada');",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: InsertKind,
+						InsertStatement: &InsertStatement{
+							Table: *tok("users", IdentifierKind, 12),
+							Cols: []Token{
+								*tok("id", IdentifierKind, 19),
+								*tok("name", IdentifierKind, 23),
+							},
+							Values: [][]*Expression{{
+								literal("1", NumericKind, 37),
+								literal("Ada", StringKind, 40),
+							}},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "insert into t values (1, 'a'), (2, 'b'), (3, 'c');",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: InsertKind,
+						InsertStatement: &InsertStatement{
+							Table: *tok("t", IdentifierKind, 12),
+							Values: [][]*Expression{
+								{literal("1", NumericKind, 22), literal("a", StringKind, 25)},
+								{literal("2", NumericKind, 32), literal("b", StringKind, 35)},
+								{literal("3", NumericKind, 42), literal("c", StringKind, 45)},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "insert into archive select * from events where ts < 100;",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: InsertKind,
+						InsertStatement: &InsertStatement{
+							Table: *tok("archive", IdentifierKind, 12),
+							Select: &SelectStatement{
+								Item: []*SelectItem{{Asterisk: true}},
+								From: &TableReference{Name: tok("events", IdentifierKind, 34)},
+								Where: &Expression{
+									Binary: &BinaryExpression{
+										A:  columnRef("ts", 47),
+										B:  literal("100", NumericKind, 52),
+										Op: *tok("<", OperatorKind, 50),
+									},
+									Kind: BinaryKind,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "create table users (id int, name text);",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: CreateTableKind,
+						CreateTableStatement: &CreateTableStatement{
+							Name: *tok("users", IdentifierKind, 13),
+							Cols: []*ColumnDefinition{
+								{
+									Name:     *tok("id", IdentifierKind, 20),
+									Datatype: *tok("int", KeywordKind, 23),
+								},
+								{
+									Name:     *tok("name", IdentifierKind, 28),
+									Datatype: *tok("text", KeywordKind, 33),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "create table if not exists users (id int);",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: CreateTableKind,
+						CreateTableStatement: &CreateTableStatement{
+							Name: *tok("users", IdentifierKind, 27),
+							Cols: []*ColumnDefinition{
+								{
+									Name:     *tok("id", IdentifierKind, 34),
+									Datatype: *tok("int", KeywordKind, 37),
+								},
+							},
+							IfNotExists: true,
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "create table t (id int primary key, name text);",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: CreateTableKind,
+						CreateTableStatement: &CreateTableStatement{
+							Name: *tok("t", IdentifierKind, 13),
+							Cols: []*ColumnDefinition{
+								{
+									Name:       *tok("id", IdentifierKind, 16),
+									Datatype:   *tok("int", KeywordKind, 19),
+									PrimaryKey: true,
+								},
+								{
+									Name:     *tok("name", IdentifierKind, 36),
+									Datatype: *tok("text", KeywordKind, 41),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "create table t (id int not null primary key, name text null);",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: CreateTableKind,
+						CreateTableStatement: &CreateTableStatement{
+							Name: *tok("t", IdentifierKind, 13),
+							Cols: []*ColumnDefinition{
+								{
+									Name:       *tok("id", IdentifierKind, 16),
+									Datatype:   *tok("int", KeywordKind, 19),
+									PrimaryKey: true,
+									NotNull:    true,
+								},
+								{
+									Name:     *tok("name", IdentifierKind, 45),
+									Datatype: *tok("text", KeywordKind, 50),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "create table t (email text unique not null, a int, b int, unique (a, b));",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: CreateTableKind,
+						CreateTableStatement: &CreateTableStatement{
+							Name: *tok("t", IdentifierKind, 13),
+							Cols: []*ColumnDefinition{
+								{
+									Name:     *tok("email", IdentifierKind, 16),
+									Datatype: *tok("text", KeywordKind, 22),
+									Unique:   true,
+									NotNull:  true,
+								},
+								{
+									Name:     *tok("a", IdentifierKind, 44),
+									Datatype: *tok("int", KeywordKind, 46),
+								},
+								{
+									Name:     *tok("b", IdentifierKind, 51),
+									Datatype: *tok("int", KeywordKind, 53),
+								},
+							},
+							UniqueConstraints: [][]Token{{
+								*tok("a", IdentifierKind, 66),
+								*tok("b", IdentifierKind, 69),
+							}},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "create table t (ttl int default 60 * 60 not null, status text default 'new');",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: CreateTableKind,
+						CreateTableStatement: &CreateTableStatement{
+							Name: *tok("t", IdentifierKind, 13),
+							Cols: []*ColumnDefinition{
+								{
+									Name:     *tok("ttl", IdentifierKind, 16),
+									Datatype: *tok("int", KeywordKind, 20),
+									Default: &Expression{
+										Binary: &BinaryExpression{
+											A:  literal("60", NumericKind, 32),
+											B:  literal("60", NumericKind, 37),
+											Op: *tok("*", OperatorKind, 35),
+										},
+										Kind: BinaryKind,
+									},
+									NotNull: true,
+								},
+								{
+									Name:     *tok("status", IdentifierKind, 50),
+									Datatype: *tok("text", KeywordKind, 57),
+									Default:  literal("new", StringKind, 70),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "create table t (age int check (age > 0), a int, check (a < age));",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: CreateTableKind,
+						CreateTableStatement: &CreateTableStatement{
+							Name: *tok("t", IdentifierKind, 13),
+							Cols: []*ColumnDefinition{
+								{
+									Name:     *tok("age", IdentifierKind, 16),
+									Datatype: *tok("int", KeywordKind, 20),
+									Checks: []*Expression{{
+										Binary: &BinaryExpression{
+											A:  columnRef("age", 31),
+											B:  literal("0", NumericKind, 37),
+											Op: *tok(">", OperatorKind, 35),
+										},
+										Kind: BinaryKind,
+									}},
+								},
+								{
+									Name:     *tok("a", IdentifierKind, 41),
+									Datatype: *tok("int", KeywordKind, 43),
+								},
+							},
+							Checks: []*Expression{{
+								Binary: &BinaryExpression{
+									A:  columnRef("a", 55),
+									B:  columnRef("age", 59),
+									Op: *tok("<", OperatorKind, 57),
+								},
+								Kind: BinaryKind,
+							}},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "create table t (user_id int references users (id), a int, foreign key (a) references u);",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: CreateTableKind,
+						CreateTableStatement: &CreateTableStatement{
+							Name: *tok("t", IdentifierKind, 13),
+							Cols: []*ColumnDefinition{
+								{
+									Name:     *tok("user_id", IdentifierKind, 16),
+									Datatype: *tok("int", KeywordKind, 24),
+									References: &ForeignKey{
+										Cols:    []Token{*tok("user_id", IdentifierKind, 16)},
+										Table:   *tok("users", IdentifierKind, 39),
+										RefCols: []Token{*tok("id", IdentifierKind, 46)},
+									},
+								},
+								{
+									Name:     *tok("a", IdentifierKind, 51),
+									Datatype: *tok("int", KeywordKind, 53),
+								},
+							},
+							ForeignKeys: []*ForeignKey{{
+								Cols:  []Token{*tok("a", IdentifierKind, 71)},
+								Table: *tok("u", IdentifierKind, 85),
+							}},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "create table t (id int, name text, primary key (id, name));",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: CreateTableKind,
+						CreateTableStatement: &CreateTableStatement{
+							Name: *tok("t", IdentifierKind, 13),
+							Cols: []*ColumnDefinition{
+								{
+									Name:     *tok("id", IdentifierKind, 16),
+									Datatype: *tok("int", KeywordKind, 19),
+								},
+								{
+									Name:     *tok("name", IdentifierKind, 24),
+									Datatype: *tok("text", KeywordKind, 29),
+								},
+							},
+							PrimaryKey: []Token{
+								*tok("id", IdentifierKind, 48),
+								*tok("name", IdentifierKind, 52),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "delete from t;",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind:            DeleteKind,
+						DeleteStatement: &DeleteStatement{From: *tok("t", IdentifierKind, 12)},
+					},
+				},
+			},
+		},
+		{
+			source: "delete from t where id = 5;",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: DeleteKind,
+						DeleteStatement: &DeleteStatement{
+							From: *tok("t", IdentifierKind, 12),
+							Where: &Expression{
+								Binary: &BinaryExpression{
+									A:  columnRef("id", 20),
+									B:  literal("5", NumericKind, 25),
+									Op: *tok("=", OperatorKind, 23),
+								},
+								Kind: BinaryKind,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "update users set name = 'Ada', age = age + 1 where id = 3;",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: UpdateKind,
+						UpdateStatement: &UpdateStatement{
+							Table: *tok("users", IdentifierKind, 7),
+							Set: []*Assignment{
+								{
+									Column: *tok("name", IdentifierKind, 17),
+									Value:  literal("Ada", StringKind, 24),
+								},
+								{
+									Column: *tok("age", IdentifierKind, 31),
+									Value: &Expression{
+										Binary: &BinaryExpression{
+											A:  columnRef("age", 37),
+											B:  literal("1", NumericKind, 43),
+											Op: *tok("+", OperatorKind, 41),
+										},
+										Kind: BinaryKind,
+									},
+								},
+							},
+							Where: &Expression{
+								Binary: &BinaryExpression{
+									A:  columnRef("id", 51),
+									B:  literal("3", NumericKind, 56),
+									Op: *tok("=", OperatorKind, 54),
+								},
+								Kind: BinaryKind,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "create unique index idx_name on users (last, first);",
+			ast: &Ast{
+				Statements: []*Statement{
+					{
+						Kind: CreateIndexKind,
+						CreateIndexStatement: &CreateIndexStatement{
+							Name:   *tok("idx_name", IdentifierKind, 20),
+							Table:  *tok("users", IdentifierKind, 32),
+							Unique: true,
+							Cols: []Token{
+								*tok("last", IdentifierKind, 39),
+								*tok("first", IdentifierKind, 45),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			source: "",
+			ast:    &Ast{},
+		},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse(test.source)
+		assert.Nil(t, err, test.source)
+		stripSpans(reflect.ValueOf(ast))
+		assert.Equal(t, test.ast, ast, test.source)
+	}
+}
+
+// stripSpans 清除语法树节点的 Start 和 End，token 的位置保持不变。
+// 节点的位置由 TestParse_locations 单独检查。
+func stripSpans(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			stripSpans(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			stripSpans(v.Index(i))
+		}
+	case reflect.Struct:
+		if _, ok := v.Addr().Interface().(*Token); ok {
+			return
+		}
+		if l, ok := v.Addr().Interface().(*Location); ok {
+			*l = Location{}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			stripSpans(v.Field(i))
+		}
+	}
+}
+
+// sexp 把表达式写成带完整括号的前缀形式，用来断言树的形状
+func sexp(e *Expression) string {
+	switch e.Kind {
+	case BinaryKind:
+		return "(" + e.Binary.Op.Value + " " + sexp(e.Binary.A) + " " + sexp(e.Binary.B) + ")"
+	case UnaryKind:
+		return "(" + e.Unary.Op.Value + " " + sexp(e.Unary.Operand) + ")"
+	case FunctionCallKind:
+		args := []string{}
+		if e.Call.Asterisk {
+			args = append(args, "*")
+		}
+		for _, arg := range e.Call.Args {
+			args = append(args, sexp(arg))
+		}
+		if e.Call.Distinct {
+			return e.Call.Name.Value + "(distinct " + strings.Join(args, " ") + ")"
+		}
+		return e.Call.Name.Value + "(" + strings.Join(args, " ") + ")"
+	case CastKind:
+		return "(:: " + sexp(e.Cast.Exp) + " " + e.Cast.Type.Value + ")"
+	case InKind:
+		op := "in"
+		if e.In.Not {
+			op = "not in"
+		}
+		if e.In.Select != nil {
+			return "(" + op + " " + sexp(e.In.Left) + " (select))"
+		}
+		items := []string{}
+		for _, exp := range e.In.List {
+			items = append(items, sexp(exp))
+		}
+		return "(" + op + " " + sexp(e.In.Left) + " [" + strings.Join(items, " ") + "])"
+	case BetweenKind:
+		op := "between"
+		if e.Between.Not {
+			op = "not between"
+		}
+		return "(" + op + " " + sexp(e.Between.Exp) + " " + sexp(e.Between.Low) + " " + sexp(e.Between.High) + ")"
+	case LikeKind:
+		op := "like"
+		if e.Like.Not {
+			op = "not like"
+		}
+		if e.Like.Escape != nil {
+			return "(" + op + " " + sexp(e.Like.Exp) + " " + sexp(e.Like.Pattern) + " " + sexp(e.Like.Escape) + ")"
+		}
+		return "(" + op + " " + sexp(e.Like.Exp) + " " + sexp(e.Like.Pattern) + ")"
+	case IsNullKind:
+		if e.IsNull.Not {
+			return "(is-not-null " + sexp(e.IsNull.Exp) + ")"
+		}
+		return "(is-null " + sexp(e.IsNull.Exp) + ")"
+	case ExistsKind:
+		return "(exists (select))"
+	case ColumnKind:
+		if e.Column.Qualifier == nil {
+			return e.Column.Name.Value
+		}
+		return e.Column.Qualifier.Value + "." + e.Column.Name.Value
+	case TypedLiteralKind:
+		return "(" + e.TypedLiteral.Type.Value + " " + e.TypedLiteral.Value.Value + ")"
+	case CaseKind:
+		parts := []string{"case"}
+		if e.Case.Operand != nil {
+			parts = append(parts, sexp(e.Case.Operand))
+		}
+		for _, w := range e.Case.Whens {
+			parts = append(parts, "["+sexp(w.When)+" "+sexp(w.Then)+"]")
+		}
+		if e.Case.Else != nil {
+			parts = append(parts, "[else "+sexp(e.Case.Else)+"]")
+		}
+		return "(" + strings.Join(parts, " ") + ")"
+	}
+	return e.Literal.Value
+}
+
+func TestParse_precedence(t *testing.T) {
+	tests := []struct {
+		source string
+		tree   string
+	}{
+		{"1 + 2 * 3", "(+ 1 (* 2 3))"},
+		{"1 * 2 + 3", "(+ (* 1 2) 3)"},
+		{"1 - 2 - 3", "(- (- 1 2) 3)"},
+		{"8 / 4 / 2", "(/ (/ 8 4) 2)"},
+		{"a or b and c", "(or a (and b c))"},
+		{"a and b or c", "(or (and a b) c)"},
+		{"a or b or c", "(or (or a b) c)"},
+		{"not a and b", "(and (not a) b)"},
+		{"not a = b", "(not (= a b))"},
+		{"not not a", "(not (not a))"},
+		{"a between 1 and 2 and b = 3", "(and (between a 1 2) (= b 3))"},
+		{"a and b between 1 and 2 and c", "(and (and a (between b 1 2)) c)"},
+		{"a = 1 and b > 2", "(and (= a 1) (> b 2))"},
+		{"a + 1 >= b * 2", "(>= (+ a 1) (* b 2))"},
+		{"a <> b or c != d", "(or (<> a b) (<> c d))"},
+		{"-a * b", "(* (- a) b)"},
+		{"-a || b", "(- (|| a b))"},
+		{"a || b || c", "(|| (|| a b) c)"},
+		{"a || b * c", "(* (|| a b) c)"},
+		{"1 - -2", "(- 1 -2)"},
+		{"- -a", "(- (- a))"},
+		{"+a % 3", "(% (+ a) 3)"},
+		{"x - 1 < y and not z or w", "(or (and (< (- x 1) y) (not z)) w)"},
+		{"(a or b) and c", "(and (or a b) c)"},
+		{"a or (b and c)", "(or a (and b c))"},
+		{"(1 + 2) * 3", "(* (+ 1 2) 3)"},
+		{"1 - (2 - 3)", "(- 1 (- 2 3))"},
+		{"((1))", "1"},
+		{"(((a + b)))", "(+ a b)"},
+		{"-(a + b)", "(- (+ a b))"},
+		{"not (a and b)", "(not (and a b))"},
+		{"(not a) = b", "(= (not a) b)"},
+		{"-(5)", "(- 5)"},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse("select " + test.source + " from t")
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		assert.Equal(t, test.tree, sexp(ast.Statements[0].SelectStatement.Item[0].Exp), test.source)
+	}
+}
+
+func TestParse_asterisk(t *testing.T) {
+	ast, err := Parse(`select * from t; select *, id, "*" from t; select a * 2 from t`)
+	assert.Nil(t, err)
+
+	items := ast.Statements[0].SelectStatement.Item
+	assert.Equal(t, []*SelectItem{{Asterisk: true}}, items)
+
+	// * 可以和其他列混用，名字叫 * 的列是列名
+	items = ast.Statements[1].SelectStatement.Item
+	assert.Equal(t, 3, len(items))
+	assert.True(t, items[0].Asterisk)
+	assert.False(t, items[1].Asterisk)
+	assert.Equal(t, "id", items[1].Exp.Column.Name.Value)
+	assert.False(t, items[2].Asterisk)
+	assert.Equal(t, ColumnKind, items[2].Exp.Kind)
+	assert.Equal(t, "*", items[2].Exp.Column.Name.Value)
+	assert.Equal(t, IdentifierKind, items[2].Exp.Column.Name.Kind)
+
+	// 表达式中的 * 是乘法
+	items = ast.Statements[2].SelectStatement.Item
+	assert.False(t, items[0].Asterisk)
+	assert.Equal(t, "(* a 2)", sexp(items[0].Exp))
+
+	_, err = Parse("select * * from t")
+	assert.EqualError(t, err, `expected ',', FROM or ';' but found operator "*" at line 1 col 10`)
+
+	_, err = Parse("select *, from t")
+	assert.EqualError(t, err, `expected expression but found keyword "from" at line 1 col 11`)
+}
+
+func TestParse_distinct(t *testing.T) {
+	ast, err := Parse("select distinct city from users; select distinct * from t; select city from users")
+	assert.Nil(t, err)
+
+	slct := ast.Statements[0].SelectStatement
+	assert.True(t, slct.Distinct)
+	assert.Equal(t, "city", sexp(slct.Item[0].Exp))
+
+	slct = ast.Statements[1].SelectStatement
+	assert.True(t, slct.Distinct)
+	assert.Equal(t, []*SelectItem{{Asterisk: true}}, slct.Item)
+
+	assert.False(t, ast.Statements[2].SelectStatement.Distinct)
+
+	_, err = Parse("select distinct from t")
+	assert.EqualError(t, err, `expected expression but found keyword "from" at line 1 col 17`)
+
+	_, err = Parse("select distinct")
+	assert.EqualError(t, err, "expected expression but found end of input at line 1 col 16")
+}
+
+func TestParse_functionCall(t *testing.T) {
+	tests := []struct {
+		source string
+		tree   string
+	}{
+		{"count(*)", "count(*)"},
+		{"sum(price)", "sum(price)"},
+		{"avg(x + y)", "avg((+ x y))"},
+		{"MIN(a), 1", "min(a)"},
+		{"max(a) - min(a)", "(- max(a) min(a))"},
+		{"count(distinct user_id)", "count(distinct user_id)"},
+		{"sum(count(*))", "sum(count(*))"},
+		{"(count(*))", "count(*)"},
+		{"-sum(a) * 2", "(* (- sum(a)) 2)"},
+		{"upper(name)", "upper(name)"},
+		{"coalesce(a, b, 0)", "coalesce(a b 0)"},
+		{"upper(trim(name))", "upper(trim(name))"},
+		{"now()", "now()"},
+		{"count (x)", "count(x)"},
+		{"length(s) > 3", "(> length(s) 3)"},
+		{`"My Func"(1)`, "My Func(1)"},
+		{"count", "count"},
+		{"upper + 1", "(+ upper 1)"},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse("select " + test.source + " from t")
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		assert.Equal(t, test.tree, sexp(ast.Statements[0].SelectStatement.Item[0].Exp), test.source)
+	}
+
+	// 聚合函数可以出现在任何需要表达式的地方
+	ast, err := Parse("select dept, count(*) from emp group by dept having count(*) > 3 order by sum(salary) desc")
+	assert.Nil(t, err)
+	slct := ast.Statements[0].SelectStatement
+	assert.Equal(t, "count(*)", sexp(slct.Item[1].Exp))
+	assert.Equal(t, "(> count(*) 3)", sexp(slct.Having))
+	assert.Equal(t, "sum(salary)", sexp(slct.OrderBy[0].Exp))
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select sum(a from t",
+			err:    `expected ')' to close '(' from line 1 col 11 but found keyword "from" at line 1 col 14`,
+		},
+		{
+			source: "select count(*",
+			err:    "expected ')' to close '(' from line 1 col 13 but found end of input at line 1 col 15",
+		},
+		{
+			source: "select f(a,) from t",
+			err:    `expected expression but found symbol ")" at line 1 col 12`,
+		},
+		{
+			source: "select f(, a) from t",
+			err:    `expected expression but found symbol "," at line 1 col 10`,
+		},
+		{
+			source: "select sum(*) from t",
+			err:    `expected expression but found operator "*" at line 1 col 12`,
+		},
+		{
+			source: "select count(distinct) from t",
+			err:    `expected expression but found symbol ")" at line 1 col 22`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_case(t *testing.T) {
+	tests := []struct {
+		source string
+		tree   string
+	}{
+		{
+			"case when score > 90 then 'A' when score > 80 then 'B' else 'F' end",
+			"(case [(> score 90) A] [(> score 80) B] [else F])",
+		},
+		{"case status when 1 then 'new' end", "(case status [1 new])"},
+		{"case a + 1 when 2 then 3 else 4 end * 5", "(* (case (+ a 1) [2 3] [else 4]) 5)"},
+		{
+			"case when a then case b when 1 then 'x' else 'y' end else 'z' end",
+			"(case [a (case b [1 x] [else y])] [else z])",
+		},
+		{"case when not a and b then 1 end", "(case [(and (not a) b) 1])"},
+		{"upper(case when a then 'x' end)", "upper((case [a x]))"},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse("select " + test.source + " from t")
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		assert.Equal(t, test.tree, sexp(ast.Statements[0].SelectStatement.Item[0].Exp), test.source)
+	}
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select case when a then 1 from t",
+			err:    `CASE is missing END at line 1 col 8`,
+		},
+		{
+			source: "select a,\n  case end from t",
+			err:    `CASE needs at least one WHEN at line 2 col 3`,
+		},
+		{
+			source: "select case status else 1 end from t",
+			err:    `CASE needs at least one WHEN at line 1 col 8`,
+		},
+		{
+			source: "select case when a 1 end from t",
+			err:    `expected THEN but found numeric "1" at line 1 col 20`,
+		},
+		{
+			source: "select case when then 1 end from t",
+			err:    `expected expression but found keyword "then" at line 1 col 18`,
+		},
+		{
+			source: "select case when a then end from t",
+			err:    `expected expression but found keyword "end" at line 1 col 25`,
+		},
+		{
+			source: "select case when a then 1 else end from t",
+			err:    `expected expression but found keyword "end" at line 1 col 32`,
+		},
+		{
+			source: "select case when a then case when b then 1 end from t",
+			err:    `CASE is missing END at line 1 col 8`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_cast(t *testing.T) {
+	tests := []struct {
+		source string
+		tree   string
+	}{
+		{"cast('5' as int)", "(:: 5 int)"},
+		{"'5'::int", "(:: 5 int)"},
+		{"x::text::int", "(:: (:: x text) int)"},
+		{"CAST(a + 1 AS TEXT)", "(:: (+ a 1) text)"},
+		{"a + b::int", "(+ a (:: b int))"},
+		{"(a + b)::int", "(:: (+ a b) int)"},
+		{"-a::int", "(- (:: a int))"},
+		{"-5::text", "(:: -5 text)"},
+		{"cast(x as int)::text", "(:: (:: x int) text)"},
+		{"upper(name::text)", "upper((:: name text))"},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse("select " + test.source + " from t")
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		assert.Equal(t, test.tree, sexp(ast.Statements[0].SelectStatement.Item[0].Exp), test.source)
+	}
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select cast(x as) from t",
+			err:    `expected INT, TEXT, BOOLEAN, REAL, FLOAT, NUMERIC, DECIMAL, VARCHAR, CHAR, BIGINT, DATE, TIME or TIMESTAMP but found symbol ")" at line 1 col 17`,
+		},
+		{
+			source: "select x::",
+			err:    "expected INT, TEXT, BOOLEAN, REAL, FLOAT, NUMERIC, DECIMAL, VARCHAR, CHAR, BIGINT, DATE, TIME or TIMESTAMP but found end of input at line 1 col 11",
+		},
+		{
+			source: "select x::blob from t",
+			err:    `expected INT, TEXT, BOOLEAN, REAL, FLOAT, NUMERIC, DECIMAL, VARCHAR, CHAR, BIGINT, DATE, TIME or TIMESTAMP but found identifier "blob" at line 1 col 11`,
+		},
+		{
+			source: "select cast(x int) from t",
+			err:    `expected AS but found keyword "int" at line 1 col 15`,
+		},
+		{
+			source: "select cast x as int from t",
+			err:    `expected '(' but found identifier "x" at line 1 col 13`,
+		},
+		{
+			source: "select cast(x as int from t",
+			err:    `expected ')' to close '(' from line 1 col 12 but found keyword "from" at line 1 col 22`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_alias(t *testing.T) {
+	tests := []struct {
+		source string
+		exps   []string
+		as     []string
+	}{
+		{"select id as identifier from t", []string{"id"}, []string{"identifier"}},
+		{"select id identifier from t", []string{"id"}, []string{"identifier"}},
+		{`select id as "Id", name "full name" from t`, []string{"id", "name"}, []string{"Id", "full name"}},
+		{"select a + 1 as b, c from t", []string{"(+ a 1)", "c"}, []string{"b", ""}},
+		{`select 1 "select" from t`, []string{"1"}, []string{"select"}},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse(test.source)
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		items := ast.Statements[0].SelectStatement.Item
+		assert.Equal(t, len(test.exps), len(items), test.source)
+		for i, item := range items {
+			assert.Equal(t, test.exps[i], sexp(item.Exp), test.source)
+			if test.as[i] == "" {
+				assert.Nil(t, item.As, test.source)
+			} else {
+				assert.Equal(t, test.as[i], item.As.Value, test.source)
+			}
+		}
+	}
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select id as from t",
+			err:    `expected identifier but found keyword "from" at line 1 col 14`,
+		},
+		{
+			source: "select id as",
+			err:    "expected identifier but found end of input at line 1 col 13",
+		},
+		{
+			source: "select id as 'x' from t",
+			err:    `expected identifier but found string "x" at line 1 col 14`,
+		},
+		{
+			source: "select id select from t",
+			err:    `expected ',', FROM or ';' but found keyword "select" at line 1 col 11`,
+		},
+		{
+			source: "select * as x from t",
+			err:    `expected ',', FROM or ';' but found keyword "as" at line 1 col 10`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_tableAlias(t *testing.T) {
+	tests := []struct {
+		source string
+		name   string
+		alias  string
+	}{
+		{"select id from users u", "users", "u"},
+		{"select id from users as u", "users", "u"},
+		{`select id from users "select"`, "users", "select"},
+		{"select id from users", "users", ""},
+		{"select id from users where id = 1", "users", ""},
+		{"select id from users u where id = 1", "users", "u"},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse(test.source)
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		from := ast.Statements[0].SelectStatement.From
+		assert.Equal(t, test.name, from.Name.Value, test.source)
+		if test.alias == "" {
+			assert.Nil(t, from.Alias, test.source)
+		} else {
+			assert.Equal(t, test.alias, from.Alias.Value, test.source)
+		}
+	}
+
+	_, err := Parse("select id from users as where id = 1")
+	assert.EqualError(t, err, `expected identifier but found keyword "where" at line 1 col 25`)
+}
+
+func TestParse_withoutFrom(t *testing.T) {
+	tests := []struct {
+		source string
+		items  []string
+	}{
+		{"select 1;", []string{"1"}},
+		{"select 'a' || 'b';", []string{"(|| a b)"}},
+		{"select 1 + 1, 2 as two", []string{"(+ 1 1)", "2"}},
+		{"select 1 where true;", []string{"1"}},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse(test.source)
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		slct := ast.Statements[0].SelectStatement
+		assert.Nil(t, slct.From, test.source)
+		var items []string
+		for _, item := range slct.Item {
+			items = append(items, sexp(item.Exp))
+		}
+		assert.Equal(t, test.items, items, test.source)
+	}
+
+	_, err := Parse("select from t;")
+	assert.EqualError(t, err, `expected expression but found keyword "from" at line 1 col 8`)
+
+	_, err = Parse("select 1 2;")
+	assert.EqualError(t, err, `expected ',', FROM or ';' but found numeric "2" at line 1 col 10`)
+}
+
+func TestParse_where(t *testing.T) {
+	tests := []struct {
+		source string
+		where  string
+	}{
+		{"select a from t", ""},
+		{"select a from t where a = 1", "(= a 1)"},
+		{"select a from t where a = 1 and b = 'x'", "(and (= a 1) (= b x))"},
+		{"select a from t where (a = 1 or a = 2) and not b", "(and (or (= a 1) (= a 2)) (not b))"},
+		{"select a from t WHERE $1", "$1"},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse(test.source)
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		where := ast.Statements[0].SelectStatement.Where
+		if test.where == "" {
+			assert.Nil(t, where, test.source)
+		} else {
+			assert.Equal(t, test.where, sexp(where), test.source)
+		}
+	}
+
+	_, err := Parse("select a from t where;")
+	assert.EqualError(t, err, `expected expression but found symbol ";" at line 1 col 22`)
+
+	_, err = Parse("select a from t where a = 1 b")
+	assert.EqualError(t, err, `expected ';' but found identifier "b" at line 1 col 29`)
+}
+
+func TestParse_orderBy(t *testing.T) {
+	tests := []struct {
+		source  string
+		orderBy []string
+	}{
+		{"select a from t", nil},
+		{"select a from t order by a", []string{"a asc"}},
+		{"select name, id from t where id > 1 order by name desc, id", []string{"name desc", "id asc"}},
+		{"select a from t order by a asc, b desc, c ASC", []string{"a asc", "b desc", "c asc"}},
+		{"select price from t order by price * qty desc", []string{"(* price qty) desc"}},
+		{"select 1 order by 1", []string{"1 asc"}},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse(test.source)
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		var orderBy []string
+		for _, item := range ast.Statements[0].SelectStatement.OrderBy {
+			direction := " asc"
+			if !item.Asc {
+				direction = " desc"
+			}
+			orderBy = append(orderBy, sexp(item.Exp)+direction)
+		}
+		assert.Equal(t, test.orderBy, orderBy, test.source)
+	}
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select a from t order a",
+			err:    `expected BY but found identifier "a" at line 1 col 23`,
+		},
+		{
+			source: "select a from t order by",
+			err:    "expected expression but found end of input at line 1 col 25",
+		},
+		{
+			source: "select a from t order by a,",
+			err:    "expected expression but found end of input at line 1 col 28",
+		},
+		{
+			source: "select a from t order by a desc b",
+			err:    `expected ';' but found identifier "b" at line 1 col 33`,
+		},
+		{
+			source: "select a from t order by a where a = 1",
+			err:    `expected ';' but found keyword "where" at line 1 col 28`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_groupBy(t *testing.T) {
+	ast, err := Parse("select dept, salary from emp where salary > 0 group by dept, salary / 1000 having dept <> 'x' and salary > 3 order by dept desc limit 5")
+	assert.Nil(t, err)
+	slct := ast.Statements[0].SelectStatement
+	assert.Equal(t, "(> salary 0)", sexp(slct.Where))
+	assert.Equal(t, 2, len(slct.GroupBy))
+	assert.Equal(t, "dept", sexp(slct.GroupBy[0]))
+	assert.Equal(t, "(/ salary 1000)", sexp(slct.GroupBy[1]))
+	assert.Equal(t, "(and (<> dept x) (> salary 3))", sexp(slct.Having))
+	assert.Equal(t, 1, len(slct.OrderBy))
+	assert.False(t, slct.OrderBy[0].Asc)
+	assert.Equal(t, "5", sexp(slct.Limit))
+
+	// 没有 group by 的 having 作用在隐含的单个分组上
+	ast, err = Parse("select 1 from t having 1 = 1")
+	assert.Nil(t, err)
+	slct = ast.Statements[0].SelectStatement
+	assert.Nil(t, slct.GroupBy)
+	assert.Equal(t, "(= 1 1)", sexp(slct.Having))
+
+	ast, err = Parse("select a from t group by a")
+	assert.Nil(t, err)
+	assert.Nil(t, ast.Statements[0].SelectStatement.Having)
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select a from t group a",
+			err:    `expected BY but found identifier "a" at line 1 col 23`,
+		},
+		{
+			source: "select a from t group by",
+			err:    "expected expression but found end of input at line 1 col 25",
+		},
+		{
+			source: "select a from t group by a having",
+			err:    "expected expression but found end of input at line 1 col 34",
+		},
+		{
+			source: "select a from t having a group by a",
+			err:    `expected ';' but found keyword "group" at line 1 col 26`,
+		},
+		{
+			source: "select a from t group by a where a = 1",
+			err:    `expected ';' but found keyword "where" at line 1 col 28`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_limit(t *testing.T) {
+	tests := []struct {
+		source string
+		limit  string
+		offset string
+	}{
+		{"select a from t", "", ""},
+		{"select a from t limit 10", "10", ""},
+		{"select a from t limit 10 offset 20", "10", "20"},
+		{"select a from t offset 5", "", "5"},
+		{"select a from t limit $1 offset :skip", "$1", "skip"},
+		{"select a from t where a > 1 order by a desc limit 0", "0", ""},
+		{"select 1 limit 1", "1", ""},
+		{"select a from t limit 0x10", "0x10", ""},
+		{"select a from t limit 0X1f offset 0x0", "0X1f", "0x0"},
+		{"select a from t limit 09", "09", ""},
+		{"select a from t limit 010 offset 0009", "010", "0009"},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse(test.source)
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		slct := ast.Statements[0].SelectStatement
+		if test.limit == "" {
+			assert.Nil(t, slct.Limit, test.source)
+		} else {
+			assert.Equal(t, test.limit, sexp(slct.Limit), test.source)
+		}
+		if test.offset == "" {
+			assert.Nil(t, slct.Offset, test.source)
+		} else {
+			assert.Equal(t, test.offset, sexp(slct.Offset), test.source)
+		}
+	}
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select a from t limit -1",
+			err:    "LIMIT must be a non-negative integer or a parameter at line 1 col 23",
+		},
+		{
+			source: "select a from t limit 10 offset 'x'",
+			err:    "OFFSET must be a non-negative integer or a parameter at line 1 col 33",
+		},
+		{
+			source: "select a from t limit 1e3",
+			err:    "LIMIT must be a non-negative integer or a parameter at line 1 col 23",
+		},
+		{
+			source: "select a from t limit 1.5",
+			err:    "LIMIT must be a non-negative integer or a parameter at line 1 col 23",
+		},
+		{
+			source: "select a from t limit a",
+			err:    "LIMIT must be a non-negative integer or a parameter at line 1 col 23",
+		},
+		{
+			source: "select a from t limit 1 + 1",
+			err:    "LIMIT must be a non-negative integer or a parameter at line 1 col 23",
+		},
+		{
+			source: "select a from t limit",
+			err:    "expected row count after LIMIT but found end of input at line 1 col 22",
+		},
+		{
+			source: "select a from t limit 10 order by a",
+			err:    "ORDER BY must come before LIMIT and OFFSET at line 1 col 26",
+		},
+		{
+			source: "select a from t offset 1 limit 10",
+			err:    `expected ';' but found keyword "limit" at line 1 col 26`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_binaryErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select 1 + from t",
+			err:    `expected expression but found keyword "from" at line 1 col 12`,
+		},
+		{
+			source: "select a and from t",
+			err:    `expected expression but found keyword "from" at line 1 col 14`,
+		},
+		{
+			source: "select not from t",
+			err:    `expected expression but found keyword "from" at line 1 col 12`,
+		},
+		{
+			source: "insert into t values (1 *)",
+			err:    `expected expression but found symbol ")" at line 1 col 26`,
+		},
+		{
+			source: "select (1 + 2 from t",
+			err:    `expected ')' to close '(' from line 1 col 8 but found keyword "from" at line 1 col 15`,
+		},
+		{
+			source: "select ((a)\n  + b",
+			err:    "expected ')' to close '(' from line 1 col 8 but found end of input at line 2 col 6",
+		},
+		{
+			source: "select (1)) from t",
+			err:    `expected ',', FROM or ';' but found symbol ")" at line 1 col 11`,
+		},
+		{
+			source: "select () from t",
+			err:    `expected expression but found symbol ")" at line 1 col 9`,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_multipleStatements(t *testing.T) {
+	ast, err := Parse("create table t (a int);\ninsert into t values (1);\nselect a from t;")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(ast.Statements))
+	assert.Equal(t, CreateTableKind, ast.Statements[0].Kind)
+	assert.Equal(t, InsertKind, ast.Statements[1].Kind)
+	assert.Equal(t, SelectKind, ast.Statements[2].Kind)
+	assert.Equal(t, Location{Line: 2, Col: 14}, ast.Statements[2].SelectStatement.From.Name.Loc)
+}
+
+func TestParse_script(t *testing.T) {
+	tests := []struct {
+		source string
+		kinds  []AstKind
+	}{
+		{
+			source: "create table t (a int); insert into t values (1); select a from t;",
+			kinds:  []AstKind{CreateTableKind, InsertKind, SelectKind},
+		},
+		{
+			source: "create table t (a int);\ninsert into t values (1);\nselect a from t",
+			kinds:  []AstKind{CreateTableKind, InsertKind, SelectKind},
+		},
+		{
+			source: ";;select a from t;; ;insert into t values (1);;",
+			kinds:  []AstKind{SelectKind, InsertKind},
+		},
+		{
+			source: "select a from t",
+			kinds:  []AstKind{SelectKind},
+		},
+		{
+			source: ";",
+			kinds:  nil,
+		},
+		{
+			source: "-- 只有注释\n",
+			kinds:  nil,
+		},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse(test.source)
+		assert.Nil(t, err, test.source)
+		assert.Equal(t, len(test.kinds), len(ast.Statements), test.source)
+		for i, stmt := range ast.Statements {
+			assert.Equal(t, test.kinds[i], stmt.Kind, test.source)
+		}
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	tests := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select a from t select b from t",
+			err:    `expected ';' but found keyword "select" at line 1 col 17`,
+		},
+		{
+			source: "select from t;",
+			err:    `expected expression but found keyword "from" at line 1 col 8`,
+		},
+		{
+			source: "select a form t;",
+			err:    `expected ',', FROM or ';' but found identifier "t" at line 1 col 15`,
+		},
+		{
+			source: "insert users values (1);",
+			err:    `expected INTO but found identifier "users" at line 1 col 8`,
+		},
+		{
+			source: "insert into t values (1, 2;",
+			err:    `expected ',' or ')' but found symbol ";" at line 1 col 27`,
+		},
+		{
+			source: "create table t (a blob);",
+			err:    `expected INT, TEXT, BOOLEAN, REAL, FLOAT, NUMERIC, DECIMAL, VARCHAR, CHAR, BIGINT, DATE, TIME or TIMESTAMP but found identifier "blob" at line 1 col 19`,
+		},
+		{
+			source: "drop table t;",
+			err:    `expected VIEW or INDEX but found keyword "table" at line 1 col 6`,
+		},
+		{
+			source: "delete t where id = 5;",
+			err:    `expected FROM but found identifier "t" at line 1 col 8`,
+		},
+		{
+			source: "delete from t where;",
+			err:    `expected expression but found symbol ";" at line 1 col 20`,
+		},
+		{
+			source: "delete from t where (id = 1 or id = 2;",
+			err:    `expected ')' to close '(' from line 1 col 21 but found symbol ";" at line 1 col 38`,
+		},
+		{
+			source: "delete from t id = 5;",
+			err:    `expected ';' but found identifier "id" at line 1 col 15`,
+		},
+		{
+			source: "update t set where id = 1;",
+			err:    `expected assignment after SET but found keyword "where" at line 1 col 14`,
+		},
+		{
+			source: "update t set;",
+			err:    `expected assignment after SET but found symbol ";" at line 1 col 13`,
+		},
+		{
+			source: "update t set a = 1, where id = 1;",
+			err:    `expected assignment after ',' but found keyword "where" at line 1 col 21`,
+		},
+		{
+			source: "update t set a 1;",
+			err:    `expected '=' but found numeric "1" at line 1 col 16`,
+		},
+		{
+			source: "update t set a = ;",
+			err:    `expected expression but found symbol ";" at line 1 col 18`,
+		},
+		{
+			source: "update t a = 1;",
+			err:    `expected SET but found identifier "a" at line 1 col 10`,
+		},
+		{
+			source: "select a,\n  from t;",
+			err:    `expected expression but found keyword "from" at line 2 col 3`,
+		},
+		{
+			source: "select a\nfrom\n't';",
+			err:    `expected identifier but found string "t" at line 3 col 1`,
+		},
+		{
+			source: "insert into t values 1;",
+			err:    `expected '(' but found numeric "1" at line 1 col 22`,
+		},
+		{
+			source: "insert into t values ();",
+			err:    `expected expression but found symbol ")" at line 1 col 23`,
+		},
+		{
+			source: "insert into t (1);",
+			err:    `expected identifier but found numeric "1" at line 1 col 16`,
+		},
+		{
+			source: "insert into users (id, ) values (1);",
+			err:    `expected column name after ',' in column list of users but found symbol ")" at line 1 col 24`,
+		},
+		{
+			source: "insert into users (id name) values (1);",
+			err:    `expected ',' or ')' but found identifier "name" at line 1 col 23`,
+		},
+		{
+			source: "insert into users (id) (1);",
+			err:    `expected VALUES or SELECT but found symbol "(" at line 1 col 24`,
+		},
+		{
+			source: "insert into users (id, name) values (1);",
+			err:    "insert into users has 2 columns but 1 values at line 1 col 37",
+		},
+		{
+			source: "insert into users (id) values (1, 'Ada');",
+			err:    "insert into users has 1 columns but 2 values at line 1 col 31",
+		},
+		{
+			source: "insert into t values (1, 'a'), (2), (3, 'c', 4);",
+			err:    "insert into t row 2 has 1 values but row 1 has 2 at line 1 col 32",
+		},
+		{
+			source: "insert into users (id, name) values (1, 'a'),\n  (2);",
+			err:    "insert into users has 2 columns but 1 values at line 2 col 3",
+		},
+		{
+			source: "insert into t values (1) select 1;",
+			err:    "insert into t cannot have both VALUES and SELECT at line 1 col 26",
+		},
+		{
+			source: "insert into t select a from u values (1);",
+			err:    "insert into t cannot have both SELECT and VALUES at line 1 col 31",
+		},
+		{
+			source: "insert into t (a, b) select a from u;",
+			err:    "insert into t has 2 columns but select has 1 at line 1 col 22",
+		},
+		{
+			source: "insert into t select a from u where;",
+			err:    `expected expression but found symbol ";" at line 1 col 36`,
+		},
+		{
+			source: "insert into t values (1), ;",
+			err:    `expected '(' but found symbol ";" at line 1 col 27`,
+		},
+		{
+			source: "create table (a int);",
+			err:    `expected identifier but found symbol "(" at line 1 col 14`,
+		},
+		{
+			source: "create t (a int);",
+			err:    `expected TABLE, INDEX or VIEW but found identifier "t" at line 1 col 8`,
+		},
+		{
+			source: "create table if exists t (a int);",
+			err:    `expected NOT but found keyword "exists" at line 1 col 17`,
+		},
+		{
+			source: "create table if not t (a int);",
+			err:    `expected EXISTS but found identifier "t" at line 1 col 21`,
+		},
+		{
+			source: "create table if (a int);",
+			err:    `expected NOT but found symbol "(" at line 1 col 17`,
+		},
+		{
+			source: "create table t (id int primary key, name text primary key);",
+			err:    "table t has more than one primary key at line 1 col 47",
+		},
+		{
+			source: "create table t (id int primary key, primary key (id));",
+			err:    "table t has more than one primary key at line 1 col 37",
+		},
+		{
+			source: "create table t (id int primary key primary key);",
+			err:    "table t has more than one primary key at line 1 col 36",
+		},
+		{
+			source: "create table t (name text not, id int);",
+			err:    `expected NULL but found symbol "," at line 1 col 30`,
+		},
+		{
+			source: "create table t (name text not primary key);",
+			err:    `expected NULL but found keyword "primary" at line 1 col 31`,
+		},
+		{
+			source: "create table t (a int, unique a);",
+			err:    `expected '(' but found identifier "a" at line 1 col 31`,
+		},
+		{
+			source: "create table t (a int, unique ());",
+			err:    `expected identifier but found symbol ")" at line 1 col 32`,
+		},
+		{
+			source: "create table t (a int default, b int);",
+			err:    `expected expression after DEFAULT but found symbol "," at line 1 col 30`,
+		},
+		{
+			source: "create table t (a int default);",
+			err:    `expected expression after DEFAULT but found symbol ")" at line 1 col 30`,
+		},
+		{
+			source: "create table t (a int default 1 +);",
+			err:    `expected expression but found symbol ")" at line 1 col 34`,
+		},
+		{
+			source: "create table t (age int check age > 0);",
+			err:    `expected '(' after CHECK but found identifier "age" at line 1 col 31`,
+		},
+		{
+			source: "create table t (age int check ((age > 0), b int);",
+			err:    `expected ')' to close '(' from line 1 col 31 but found symbol "," at line 1 col 41`,
+		},
+		{
+			source: "create table t (a int, check (a < ));",
+			err:    `expected expression but found symbol ")" at line 1 col 35`,
+		},
+		{
+			source: "create table t (a int, check ());",
+			err:    `expected expression but found symbol ")" at line 1 col 31`,
+		},
+		{
+			source: "create table t (a int references (id));",
+			err:    `expected table name after REFERENCES but found symbol "(" at line 1 col 34`,
+		},
+		{
+			source: "create table t (a int references);",
+			err:    `expected table name after REFERENCES but found symbol ")" at line 1 col 33`,
+		},
+		{
+			source: "create table t (a int, foreign key (a) users (id));",
+			err:    `expected REFERENCES but found identifier "users" at line 1 col 40`,
+		},
+		{
+			source: "create table t (a int, foreign (a) references u);",
+			err:    `expected KEY but found symbol "(" at line 1 col 32`,
+		},
+		{
+			source: "create table t (a int references u (id,));",
+			err:    `expected column name after ',' in column list of u but found symbol ")" at line 1 col 40`,
+		},
+		{
+			source: "create table t (id int primary, name text);",
+			err:    `expected KEY but found symbol "," at line 1 col 31`,
+		},
+		{
+			source: "create table t (id int, primary key id);",
+			err:    `expected '(' but found identifier "id" at line 1 col 37`,
+		},
+		{
+			source: "create table t (id int, primary key (id,));",
+			err:    `expected column name after ',' in column list of t but found symbol ")" at line 1 col 41`,
+		},
+		{
+			source: "create index i on t ();",
+			err:    `expected identifier but found symbol ")" at line 1 col 22`,
+		},
+		{
+			source: "create index i on t (a, b, a);",
+			err:    "index i has duplicate column a at line 1 col 28",
+		},
+		{
+			source: "create unique i on t (a);",
+			err:    `expected INDEX but found identifier "i" at line 1 col 15`,
+		},
+		{
+			source: "create index i t (a);",
+			err:    `expected ON but found identifier "t" at line 1 col 16`,
+		},
+		{
+			source: "create index on t (a);",
+			err:    `expected identifier but found keyword "on" at line 1 col 14`,
+		},
+		{
+			source: "create table t a int;",
+			err:    `expected '(' but found identifier "a" at line 1 col 16`,
+		},
+		{
+			source: "create table t (a int,);",
+			err:    `expected identifier but found symbol ")" at line 1 col 23`,
+		},
+		{
+			source: "create table t (a int",
+			err:    "expected ',' or ')' but found end of input at line 1 col 22",
+		},
+		{
+			source: "select a from t; select",
+			err:    "expected expression but found end of input at line 1 col 24",
+		},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse(test.source)
+		assert.Nil(t, ast, test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	source := `create table t (a int);
+select a form t;
+insert into t values (1);
+insert into t values (2, ;
+select a from t select b from t;
+create table u (a blob);
+select a from t`
+
+	ast, errs := ParseAll(source)
+	kinds := []AstKind{}
+	for _, stmt := range ast.Statements {
+		kinds = append(kinds, stmt.Kind)
+	}
+	assert.Equal(t, []AstKind{CreateTableKind, InsertKind, SelectKind, SelectKind}, kinds)
+
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	assert.Equal(t, []string{
+		`expected ',', FROM or ';' but found identifier "t" at line 2 col 15`,
+		`expected expression but found symbol ";" at line 4 col 26`,
+		`expected ';' but found keyword "select" at line 5 col 17`,
+		`expected INT, TEXT, BOOLEAN, REAL, FLOAT, NUMERIC, DECIMAL, VARCHAR, CHAR, BIGINT, DATE, TIME or TIMESTAMP but found identifier "blob" at line 6 col 19`,
+	}, messages)
+
+	// 词法错误和语法错误一起按位置排序
+	ast, errs = ParseAll("select from t;\nselect a from t#;\nselect c from t")
+	assert.Equal(t, 2, len(errs))
+	assert.IsType(t, &ParseError{}, errs[0])
+	assert.IsType(t, &LexError{}, errs[1])
+	assert.Equal(t, 2, len(ast.Statements))
+
+	ast, errs = ParseAll("select a from t; insert into t values (1)")
+	assert.Nil(t, errs)
+	assert.Equal(t, 2, len(ast.Statements))
+}
+
+func TestParseError(t *testing.T) {
+	_, err := Parse("select a b\n  form t;")
+	var parseErr *ParseError
+	assert.True(t, errors.As(err, &parseErr))
+	assert.Equal(t, Location{Line: 1, Col: 2}, parseErr.Loc)
+	assert.Equal(t, "form", parseErr.Found.Value)
+	assert.Equal(t, IdentifierKind, parseErr.Found.Kind)
+	assert.Equal(t, "',', FROM or ';'", parseErr.Expected)
+
+	_, err = Parse("select a from")
+	assert.True(t, errors.As(err, &parseErr))
+	assert.Nil(t, parseErr.Found)
+	assert.Equal(t, Location{Line: 0, Col: 13}, parseErr.Loc)
+	assert.EqualError(t, err, "expected identifier but found end of input at line 1 col 14")
+
+	// 语义错误没有 Expected，只有 Msg
+	_, err = Parse("insert into t (a, b) values (1)")
+	assert.True(t, errors.As(err, &parseErr))
+	assert.Equal(t, "", parseErr.Expected)
+	assert.Equal(t, "insert into t has 2 columns but 1 values", parseErr.Msg)
+	assert.Equal(t, Location{Line: 0, Col: 28}, parseErr.Loc)
+
+	// 词法错误不是 ParseError
+	_, err = Parse("select 'a")
+	assert.False(t, errors.As(err, &parseErr))
+}
+
+func TestParse_in(t *testing.T) {
+	tests := []struct {
+		source string
+		tree   string
+	}{
+		{"status in ('new', 'open')", "(in status [new open])"},
+		{"id not in (1, 2, 3)", "(not in id [1 2 3])"},
+		{"a in (1)", "(in a [1])"},
+		{"a + 1 in (b * 2, c)", "(in (+ a 1) [(* b 2) c])"},
+		{"a in (1) and b", "(and (in a [1]) b)"},
+		{"not a in (1)", "(not (in a [1]))"},
+		{"a in (1) = b", "(= (in a [1]) b)"},
+		{"id in (select user_id from orders)", "(in id (select))"},
+		{"id not in (select 1)", "(not in id (select))"},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse("select " + test.source + " from t")
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		assert.Equal(t, test.tree, sexp(ast.Statements[0].SelectStatement.Item[0].Exp), test.source)
+	}
+
+	ast, err := Parse("select id from users where id in (select user_id from orders where total > 10)")
+	assert.Nil(t, err)
+	sub := ast.Statements[0].SelectStatement.Where.In.Select
+	assert.Equal(t, "orders", sub.From.Name.Value)
+	assert.Equal(t, "(> total 10)", sexp(sub.Where))
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select a in () from t",
+			err:    "IN list must not be empty at line 1 col 14",
+		},
+		{
+			source: "select a in 1 from t",
+			err:    `expected '(' but found numeric "1" at line 1 col 13`,
+		},
+		{
+			source: "select a not 1 from t",
+			err:    `expected ',', FROM or ';' but found keyword "not" at line 1 col 10`,
+		},
+		{
+			source: "select a in (1, 2 from t",
+			err:    `expected ')' to close '(' from line 1 col 13 but found keyword "from" at line 1 col 19`,
+		},
+		{
+			source: "select a in (select b from u from t",
+			err:    `expected ')' to close '(' from line 1 col 13 but found keyword "from" at line 1 col 30`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_between(t *testing.T) {
+	tests := []struct {
+		source string
+		tree   string
+	}{
+		{"price between 10 and 20", "(between price 10 20)"},
+		{"price not between 10 and 20", "(not between price 10 20)"},
+		{"a between 1 and 2 and b = 3", "(and (between a 1 2) (= b 3))"},
+		{"b = 3 and a between 1 and 2", "(and (= b 3) (between a 1 2))"},
+		{"a between 1 and 2 or b", "(or (between a 1 2) b)"},
+		{"not a between 1 and 2", "(not (between a 1 2))"},
+		{"a + 1 between b - 1 and b * 2", "(between (+ a 1) (- b 1) (* b 2))"},
+		{"a between (b and c) and d", "(between a (and b c) d)"},
+		{"a between 1 and 2 = b", "(= (between a 1 2) b)"},
+		{"a between 1 and 2 between 3 and 4", "(between (between a 1 2) 3 4)"},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse("select " + test.source + " from t")
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		assert.Equal(t, test.tree, sexp(ast.Statements[0].SelectStatement.Item[0].Exp), test.source)
+	}
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select a between 1 from t",
+			err:    `expected AND but found keyword "from" at line 1 col 20`,
+		},
+		{
+			source: "select a between 1 or 2 from t",
+			err:    `expected AND but found keyword "or" at line 1 col 20`,
+		},
+		{
+			source: "select a between and 2 from t",
+			err:    `expected expression but found keyword "and" at line 1 col 18`,
+		},
+		{
+			source: "select a between 1 and from t",
+			err:    `expected expression but found keyword "from" at line 1 col 24`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_like(t *testing.T) {
+	tests := []struct {
+		source string
+		tree   string
+	}{
+		{"name like 'A%'", "(like name A%)"},
+		{"name not like 'A%'", "(not like name A%)"},
+		{`code like '100\%' escape '\'`, `(like code 100\% \)`},
+		{"a like 'x' and b like 'y'", "(and (like a x) (like b y))"},
+		{"a like 'x' or b not like 'y'", "(or (like a x) (not like b y))"},
+		{"not a like 'x'", "(not (like a x))"},
+		{"first || last like prefix || '%'", "(like (|| first last) (|| prefix %))"},
+		{"a like b escape c and d", "(and (like a b c) d)"},
+		{"lower(name) like $1", "(like lower(name) $1)"},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse("select " + test.source + " from t")
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		assert.Equal(t, test.tree, sexp(ast.Statements[0].SelectStatement.Item[0].Exp), test.source)
+	}
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select a like from t",
+			err:    `expected expression but found keyword "from" at line 1 col 15`,
+		},
+		{
+			source: "select a like 'x' escape from t",
+			err:    `expected expression but found keyword "from" at line 1 col 26`,
+		},
+		{
+			source: "select a escape 'x' from t",
+			err:    `expected ',', FROM or ';' but found keyword "escape" at line 1 col 10`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_isNull(t *testing.T) {
+	tests := []struct {
+		source string
+		tree   string
+	}{
+		{"deleted_at is null", "(is-null deleted_at)"},
+		{"deleted_at IS NOT NULL", "(is-not-null deleted_at)"},
+		{"a is null or b is not null", "(or (is-null a) (is-not-null b))"},
+		{"(a + b) is null", "(is-null (+ a b))"},
+		{"a + b is null", "(is-null (+ a b))"},
+		{"not a is null", "(not (is-null a))"},
+		{"a is null is not null", "(is-not-null (is-null a))"},
+		{"a = b is null", "(is-null (= a b))"},
+		{"upper(name) is null and id in (1)", "(and (is-null upper(name)) (in id [1]))"},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse("select " + test.source + " from t")
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		assert.Equal(t, test.tree, sexp(ast.Statements[0].SelectStatement.Item[0].Exp), test.source)
+	}
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select a is true from t",
+			err:    `expected NULL or NOT NULL but found bool "true" at line 1 col 13`,
+		},
+		{
+			source: "select a is from t",
+			err:    `expected NULL or NOT NULL but found keyword "from" at line 1 col 13`,
+		},
+		{
+			source: "select a is not 1 from t",
+			err:    `expected NULL but found numeric "1" at line 1 col 17`,
+		},
+		{
+			source: "select a is",
+			err:    "expected NULL or NOT NULL but found end of input at line 1 col 12",
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_exists(t *testing.T) {
+	tests := []struct {
+		source string
+		tree   string
+	}{
+		{"exists (select 1 from orders)", "(exists (select))"},
+		{"not exists (select 1 from orders)", "(not (exists (select)))"},
+		{"exists(select 1) and a", "(and (exists (select)) a)"},
+		{"a or not exists (select 1)", "(or a (not (exists (select))))"},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse("select " + test.source + " from t")
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		assert.Equal(t, test.tree, sexp(ast.Statements[0].SelectStatement.Item[0].Exp), test.source)
+	}
+
+	ast, err := Parse("select id from users where exists (select 1 from orders where user_id = 7 and total > 10 limit 1)")
+	assert.Nil(t, err)
+	sub := ast.Statements[0].SelectStatement.Where.Exists.Select
+	assert.Equal(t, "orders", sub.From.Name.Value)
+	assert.Equal(t, "(and (= user_id 7) (> total 10))", sexp(sub.Where))
+	assert.Equal(t, "1", sexp(sub.Limit))
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select exists select 1 from t",
+			err:    `expected '(' but found keyword "select" at line 1 col 15`,
+		},
+		{
+			source: "select exists (1) from t",
+			err:    `expected SELECT but found numeric "1" at line 1 col 16`,
+		},
+		{
+			source: "select exists (select 1 from t",
+			err:    "expected ')' to close '(' from line 1 col 15 but found end of input at line 1 col 31",
+		},
+		{
+			source: "select exists",
+			err:    "expected '(' but found end of input at line 1 col 14",
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_fromSubquery(t *testing.T) {
+	ast, err := Parse("select total from (select sum(x) as total from nums) as t")
+	assert.Nil(t, err)
+	from := ast.Statements[0].SelectStatement.From
+	assert.Nil(t, from.Name)
+	assert.Equal(t, "t", from.Alias.Value)
+	assert.Equal(t, "nums", from.Select.From.Name.Value)
+	assert.Equal(t, "sum(x)", sexp(from.Select.Item[0].Exp))
+	assert.Equal(t, "total", from.Select.Item[0].As.Value)
+
+	// 子查询有完整的语法，里面还可以再套子查询
+	ast, err = Parse(`select n from (
+	select distinct n from (select a + 1 as n from t where a in (select b from u)) inner_t
+	where n > 0 group by n having count(*) > 1 order by n desc limit 10
+) as outer_t where n is not null`)
+	assert.Nil(t, err)
+	outer := ast.Statements[0].SelectStatement.From
+	assert.Equal(t, "outer_t", outer.Alias.Value)
+	middle := outer.Select
+	assert.True(t, middle.Distinct)
+	assert.Equal(t, "(> n 0)", sexp(middle.Where))
+	assert.Equal(t, "10", sexp(middle.Limit))
+	inner := middle.From
+	assert.Equal(t, "inner_t", inner.Alias.Value)
+	assert.Equal(t, "t", inner.Select.From.Name.Value)
+	assert.Equal(t, "(in a (select))", sexp(inner.Select.Where))
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select * from (select 1 from t)",
+			err:    "subquery in FROM must have an alias at line 1 col 32",
+		},
+		{
+			source: "select * from (select 1 from t) where a = 1",
+			err:    "subquery in FROM must have an alias at line 1 col 33",
+		},
+		{
+			source: "select * from (select 1 from t) as",
+			err:    "expected identifier but found end of input at line 1 col 35",
+		},
+		{
+			source: "select * from (t) as x",
+			err:    `expected SELECT or VALUES but found identifier "t" at line 1 col 16`,
+		},
+		{
+			source: "select * from (select 1 from t as x",
+			err:    "expected ')' to close '(' from line 1 col 15 but found end of input at line 1 col 36",
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_join(t *testing.T) {
+	ast, err := Parse("select u.name, o.total from users u join orders o on u.id = o.user_id")
+	assert.Nil(t, err)
+	slct := ast.Statements[0].SelectStatement
+	assert.Equal(t, "u.name", sexp(slct.Item[0].Exp))
+	assert.Equal(t, "o.total", sexp(slct.Item[1].Exp))
+	assert.Equal(t, "users", slct.From.Name.Value)
+	assert.Equal(t, "u", slct.From.Alias.Value)
+	assert.Equal(t, 1, len(slct.Joins))
+	assert.Equal(t, InnerJoin, slct.Joins[0].Kind)
+	assert.Equal(t, "orders", slct.Joins[0].Table.Name.Value)
+	assert.Equal(t, "o", slct.Joins[0].Table.Alias.Value)
+	assert.Equal(t, "(= u.id o.user_id)", sexp(slct.Joins[0].On))
+	assert.Equal(t, Location{Line: 0, Col: 62}, slct.Joins[0].On.Binary.B.Column.Name.Loc)
+
+	tests := []struct {
+		source string
+		joins  []string
+	}{
+		{"select * from a inner join b on a.id = b.id", []string{"b (= a.id b.id)"}},
+		{"select * from a join b on x join c on y and z where w", []string{"b x", "c (and y z)"}},
+		{"select * from a join (select id from b) as s on a.id = s.id", []string{"(select) (= a.id s.id)"}},
+		{`select * from a join "B" on "B"."Id" = a . id`, []string{"B (= B.Id a.id)"}},
+		{"select * from a", nil},
+	}
+	for _, test := range tests {
+		ast, err := Parse(test.source)
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		var joins []string
+		for _, join := range ast.Statements[0].SelectStatement.Joins {
+			table := "(select)"
+			if join.Table.Name != nil {
+				table = join.Table.Name.Value
+			}
+			joins = append(joins, table+" "+sexp(join.On))
+		}
+		assert.Equal(t, test.joins, joins, test.source)
+	}
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select * from a join b",
+			err:    "expected ON but found end of input at line 1 col 23",
+		},
+		{
+			source: "select * from a join b where x",
+			err:    `expected ON but found keyword "where" at line 1 col 24`,
+		},
+		{
+			source: "select * from a inner b on x",
+			err:    `expected JOIN but found identifier "b" at line 1 col 23`,
+		},
+		{
+			source: "select * from a join on x",
+			err:    `expected identifier but found keyword "on" at line 1 col 22`,
+		},
+		{
+			source: "select * from a join b on",
+			err:    "expected expression but found end of input at line 1 col 26",
+		},
+		{
+			source: "select u. from u",
+			err:    `expected identifier but found keyword "from" at line 1 col 11`,
+		},
+		{
+			source: "select u.1 from u",
+			err:    `expected ',', FROM or ';' but found numeric ".1" at line 1 col 9`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_outerJoin(t *testing.T) {
+	tests := []struct {
+		source string
+		joins  []JoinKind
+	}{
+		{"select * from a left join b on x", []JoinKind{LeftJoin}},
+		{"select * from a left outer join b on x", []JoinKind{LeftJoin}},
+		{"select * from a RIGHT OUTER JOIN b on x", []JoinKind{RightJoin}},
+		{"select * from a right join b on x", []JoinKind{RightJoin}},
+		{"select * from a full join b on x", []JoinKind{FullJoin}},
+		{"select * from a full outer join b on x", []JoinKind{FullJoin}},
+		{"select * from a left join b on x join c on y full join d on z", []JoinKind{LeftJoin, InnerJoin, FullJoin}},
+	}
+	for _, test := range tests {
+		ast, err := Parse(test.source)
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		var joins []JoinKind
+		for _, join := range ast.Statements[0].SelectStatement.Joins {
+			joins = append(joins, join.Kind)
+		}
+		assert.Equal(t, test.joins, joins, test.source)
+	}
+
+	// 连接从左到右结合：(a left join b) join c，c 的条件可以引用 a 和 b
+	ast, err := Parse("select * from a left join b on a.id = b.a_id join c on b.id = c.b_id where c.x > 0")
+	assert.Nil(t, err)
+	slct := ast.Statements[0].SelectStatement
+	assert.Equal(t, "a", slct.From.Name.Value)
+	assert.Equal(t, "b", slct.Joins[0].Table.Name.Value)
+	assert.Equal(t, "(= a.id b.a_id)", sexp(slct.Joins[0].On))
+	assert.Equal(t, "c", slct.Joins[1].Table.Name.Value)
+	assert.Equal(t, "(= b.id c.b_id)", sexp(slct.Joins[1].On))
+	assert.Equal(t, "(> c.x 0)", sexp(slct.Where))
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select * from a left join b",
+			err:    "expected ON but found end of input at line 1 col 28",
+		},
+		{
+			source: "select * from a full outer join b where x",
+			err:    `expected ON but found keyword "where" at line 1 col 35`,
+		},
+		{
+			source: "select * from a left b on x",
+			err:    `expected JOIN but found identifier "b" at line 1 col 22`,
+		},
+		{
+			source: "select * from a right outer b on x",
+			err:    `expected JOIN but found identifier "b" at line 1 col 29`,
+		},
+		{
+			source: "select * from a outer join b on x",
+			err:    `expected ';' but found keyword "outer" at line 1 col 17`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+// setSexp 把集合运算写成带括号的前缀形式，select 只取第一个投影列
+func setSexp(c *CompoundSelectStatement) string {
+	operand := func(o *SetOperand) string {
+		if o.Compound != nil {
+			return setSexp(o.Compound)
+		}
+		return sexp(o.Select.Item[0].Exp)
+	}
+	parts := []string{operand(c.Selects[0])}
+	for i, op := range c.Ops {
+		name := op.Op.Value
+		if op.All {
+			name += "-all"
+		}
+		parts = append(parts, name, operand(c.Selects[i+1]))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func TestParse_setOperations(t *testing.T) {
+	tests := []struct {
+		source string
+		tree   string
+	}{
+		{"select 1 union select 2", "(1 union 2)"},
+		{"select 1 union all select 2 union select 3", "(1 union-all 2 union 3)"},
+		{"select 1 except select 2 union select 3", "(1 except 2 union 3)"},
+		{"select 1 intersect all select 2", "(1 intersect-all 2)"},
+		// intersect 比 union 和 except 结合得紧
+		{"select 1 union select 2 intersect select 3", "(1 union (2 intersect 3))"},
+		{"select 1 intersect select 2 union select 3", "((1 intersect 2) union 3)"},
+		{"select 1 intersect select 2 except select 3 intersect select 4", "((1 intersect 2) except (3 intersect 4))"},
+		// 括号改变结合顺序
+		{"(select 1 union select 2) intersect select 3", "((1 union 2) intersect 3)"},
+		{"select 1 union (select 2 union select 3)", "(1 union (2 union 3))"},
+		{"(select 1) union ((select 2))", "(1 union 2)"},
+		{"select a from t1 union select b from t2 where b > 0", "(a union b)"},
+	}
+
+	for _, test := range tests {
+		ast, err := Parse(test.source)
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		stmt := ast.Statements[0]
+		assert.Equal(t, CompoundSelectKind, stmt.Kind, test.source)
+		assert.Equal(t, test.tree, setSexp(stmt.CompoundSelectStatement), test.source)
+	}
+
+	// 括号里的单个 select 仍然是普通的 select 语句
+	ast, err := Parse("((select a from t order by a limit 1))")
+	assert.Nil(t, err)
+	assert.Equal(t, SelectKind, ast.Statements[0].Kind)
+	assert.Equal(t, "1", sexp(ast.Statements[0].SelectStatement.Limit))
+
+	ast, err = Parse("(select a from t) order by a limit 2")
+	assert.Nil(t, err)
+	assert.Equal(t, SelectKind, ast.Statements[0].Kind)
+	assert.Equal(t, 1, len(ast.Statements[0].SelectStatement.OrderBy))
+	assert.Equal(t, "2", sexp(ast.Statements[0].SelectStatement.Limit))
+
+	_, err = Parse("(select a from t limit 1) limit 2")
+	assert.EqualError(t, err, "multiple ORDER BY, LIMIT or OFFSET clauses are not allowed at line 1 col 27")
+}
+
+func TestParse_setOperationsOrderBy(t *testing.T) {
+	// 最后的 order by 和 limit 属于整个集合运算
+	ast, err := Parse("select a from t1 union all select a from t2 order by a desc limit 5 offset 1")
+	assert.Nil(t, err)
+	compound := ast.Statements[0].CompoundSelectStatement
+	assert.Equal(t, 1, len(compound.OrderBy))
+	assert.Equal(t, "a", sexp(compound.OrderBy[0].Exp))
+	assert.False(t, compound.OrderBy[0].Asc)
+	assert.Equal(t, "5", sexp(compound.Limit))
+	assert.Equal(t, "1", sexp(compound.Offset))
+	last := compound.Selects[1].Select
+	assert.Nil(t, last.OrderBy)
+	assert.Nil(t, last.Limit)
+	assert.Nil(t, last.Offset)
+
+	// intersect 嵌套在里面时也提到最外层
+	ast, err = Parse("select 1 union select 2 intersect select 3 limit 2")
+	assert.Nil(t, err)
+	compound = ast.Statements[0].CompoundSelectStatement
+	assert.Equal(t, "2", sexp(compound.Limit))
+	assert.Nil(t, compound.Selects[1].Compound.Limit)
+	assert.Nil(t, compound.Selects[1].Compound.Selects[1].Select.Limit)
+
+	// 括号里的子句只属于括号里的查询
+	ast, err = Parse("select 1 union (select 2 from t order by 1 limit 1)")
+	assert.Nil(t, err)
+	compound = ast.Statements[0].CompoundSelectStatement
+	assert.Nil(t, compound.Limit)
+	assert.Equal(t, "1", sexp(compound.Selects[1].Select.Limit))
+
+	ast, err = Parse("select 1 union (select 2 union select 3 limit 1) order by 1")
+	assert.Nil(t, err)
+	compound = ast.Statements[0].CompoundSelectStatement
+	assert.Nil(t, compound.Limit)
+	assert.Equal(t, 1, len(compound.OrderBy))
+	assert.Equal(t, "1", sexp(compound.Selects[1].Compound.Limit))
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select a from t order by a union select b from u",
+			err:    "ORDER BY, LIMIT or OFFSET before UNION must be in parentheses at line 1 col 28",
+		},
+		{
+			source: "select 1 limit 1 intersect select 2",
+			err:    "ORDER BY, LIMIT or OFFSET before INTERSECT must be in parentheses at line 1 col 18",
+		},
+		{
+			source: "select 1 union",
+			err:    "expected SELECT or '(' but found end of input at line 1 col 15",
+		},
+		{
+			source: "select 1 union all all select 2",
+			err:    `expected SELECT or '(' but found keyword "all" at line 1 col 20`,
+		},
+		{
+			source: "select 1 union (select 2",
+			err:    "expected ')' to close '(' from line 1 col 16 but found end of input at line 1 col 25",
+		},
+		{
+			source: "select 1 union (1)",
+			err:    `expected SELECT or '(' but found symbol "(" at line 1 col 16`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_values(t *testing.T) {
+	ast, err := Parse("values (1, 'a'), (2, 'b');")
+	assert.Nil(t, err)
+	stmt := ast.Statements[0]
+	assert.Equal(t, ValuesKind, stmt.Kind)
+	assert.Equal(t, 2, len(stmt.ValuesStatement.Rows))
+	assert.Equal(t, "1", sexp(stmt.ValuesStatement.Rows[0][0]))
+	assert.Equal(t, "b", sexp(stmt.ValuesStatement.Rows[1][1]))
+
+	ast, err = Parse("select v.id, name from (values (1, 'a'), (2, 'b')) as v(id, name) where v.id > 1")
+	assert.Nil(t, err)
+	from := ast.Statements[0].SelectStatement.From
+	assert.Nil(t, from.Name)
+	assert.Equal(t, "v", from.Alias.Value)
+	assert.Equal(t, 2, len(from.Values.Rows))
+	assert.Equal(t, 2, len(from.Cols))
+	assert.Equal(t, "id", from.Cols[0].Value)
+	assert.Equal(t, "name", from.Cols[1].Value)
+
+	// 列名可以省略，也可以比实际的列少
+	ast, err = Parse("select * from (values (1 + 1)) v join (select a, b from t) as s(x) on true")
+	assert.Nil(t, err)
+	slct := ast.Statements[0].SelectStatement
+	assert.Nil(t, slct.From.Cols)
+	assert.Equal(t, "(+ 1 1)", sexp(slct.From.Values.Rows[0][0]))
+	assert.Equal(t, "x", slct.Joins[0].Table.Cols[0].Value)
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "values (1, 2), (3)",
+			err:    "VALUES row 2 has 1 values but row 1 has 2 at line 1 col 16",
+		},
+		{
+			source: "values",
+			err:    "expected '(' but found end of input at line 1 col 7",
+		},
+		{
+			source: "select * from (values (1), (2, 3)) as v",
+			err:    "VALUES row 2 has 2 values but row 1 has 1 at line 1 col 28",
+		},
+		{
+			source: "select * from (values (1))",
+			err:    "subquery in FROM must have an alias at line 1 col 27",
+		},
+		{
+			source: "select * from (values (1), (2) as v",
+			err:    `expected ')' to close '(' from line 1 col 15 but found keyword "as" at line 1 col 32`,
+		},
+		{
+			source: "select * from (values (1, 2)) as v(a, b, c)",
+			err:    "v has 2 columns but 3 column names at line 1 col 35",
+		},
+		{
+			source: "select * from (select 1) as s(a, b)",
+			err:    "s has 1 columns but 2 column names at line 1 col 30",
+		},
+		{
+			source: "select * from (values (1)) as v(a,)",
+			err:    `expected column name after ',' in column list of v but found symbol ")" at line 1 col 35`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_truncate(t *testing.T) {
+	tests := []struct {
+		source string
+		tables []string
+	}{
+		{"truncate table a;", []string{"a"}},
+		{"truncate a, b;", []string{"a", "b"}},
+		{`TRUNCATE TABLE "Users", logs`, []string{"Users", "logs"}},
+	}
+	for _, test := range tests {
+		ast, err := Parse(test.source)
+		assert.Nil(t, err, test.source)
+		if err != nil {
+			continue
+		}
+		stmt := ast.Statements[0]
+		assert.Equal(t, TruncateKind, stmt.Kind, test.source)
+		var tables []string
+		for _, table := range stmt.TruncateStatement.Tables {
+			tables = append(tables, table.Value)
+		}
+		assert.Equal(t, test.tables, tables, test.source)
+	}
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "truncate a b",
+			err:    `expected ';' but found identifier "b" at line 1 col 12`,
+		},
+		{
+			source: "truncate table a where x = 1",
+			err:    `expected ';' but found keyword "where" at line 1 col 18`,
+		},
+		{
+			source: "truncate",
+			err:    "expected identifier but found end of input at line 1 col 9",
+		},
+		{
+			source: "truncate a,",
+			err:    "expected identifier but found end of input at line 1 col 12",
+		},
+		{
+			source: "truncate table table",
+			err:    `expected identifier but found keyword "table" at line 1 col 16`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_transaction(t *testing.T) {
+	ast, err := Parse(`begin;
+insert into t values (1);
+commit;
+BEGIN TRANSACTION;
+delete from t;
+rollback work;
+begin work; commit transaction; rollback`)
+	assert.Nil(t, err)
+
+	var kinds []AstKind
+	for _, stmt := range ast.Statements {
+		kinds = append(kinds, stmt.Kind)
+	}
+	assert.Equal(t, []AstKind{
+		BeginKind, InsertKind, CommitKind,
+		BeginKind, DeleteKind, RollbackKind,
+		BeginKind, CommitKind, RollbackKind,
+	}, kinds)
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "begin transaction work",
+			err:    `expected ';' but found keyword "work" at line 1 col 19`,
+		},
+		{
+			source: "commit t",
+			err:    `expected ';' but found identifier "t" at line 1 col 8`,
+		},
+		{
+			source: "transaction",
+			err:    `expected SELECT, INSERT, CREATE, DROP, DELETE, UPDATE, VALUES, TRUNCATE, BEGIN, COMMIT, ROLLBACK, EXPLAIN, SHOW or DESCRIBE but found keyword "transaction" at line 1 col 1`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_explain(t *testing.T) {
+	ast, err := Parse("explain select * from t where id = 1;")
+	assert.Nil(t, err)
+	stmt := ast.Statements[0]
+	assert.Equal(t, ExplainKind, stmt.Kind)
+	assert.False(t, stmt.ExplainStatement.Analyze)
+	inner := stmt.ExplainStatement.Statement
+	assert.Equal(t, SelectKind, inner.Kind)
+	assert.Equal(t, "t", inner.SelectStatement.From.Name.Value)
+	assert.Equal(t, "(= id 1)", sexp(inner.SelectStatement.Where))
+
+	ast, err = Parse("EXPLAIN ANALYZE delete from t where a > 1; explain select 1 union select 2")
+	assert.Nil(t, err)
+	assert.True(t, ast.Statements[0].ExplainStatement.Analyze)
+	assert.Equal(t, DeleteKind, ast.Statements[0].ExplainStatement.Statement.Kind)
+	assert.Equal(t, CompoundSelectKind, ast.Statements[1].ExplainStatement.Statement.Kind)
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "explain explain select 1",
+			err:    "cannot EXPLAIN an EXPLAIN statement at line 1 col 9",
+		},
+		{
+			source: "explain analyze explain select 1",
+			err:    "cannot EXPLAIN an EXPLAIN statement at line 1 col 17",
+		},
+		{
+			source: "explain",
+			err:    "expected SELECT, INSERT, CREATE, DROP, DELETE, UPDATE, VALUES, TRUNCATE, BEGIN, COMMIT, ROLLBACK, EXPLAIN, SHOW or DESCRIBE but found end of input at line 1 col 8",
+		},
+		{
+			source: "explain select from t",
+			err:    `expected expression but found keyword "from" at line 1 col 16`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_show(t *testing.T) {
+	ast, err := Parse("show tables; SHOW TABLES")
+	assert.Nil(t, err)
+	for _, stmt := range ast.Statements {
+		assert.Equal(t, ShowKind, stmt.Kind)
+		assert.Equal(t, ShowTables, stmt.ShowStatement.Target)
+	}
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "show foo;",
+			err:    `expected TABLES but found identifier "foo" at line 1 col 6`,
+		},
+		{
+			source: "show",
+			err:    "expected TABLES but found end of input at line 1 col 5",
+		},
+		{
+			source: "show tables from t",
+			err:    `expected ';' but found keyword "from" at line 1 col 13`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_describe(t *testing.T) {
+	ast, err := Parse(`describe users; DESC "Orders"; select a from t order by a desc; desc t`)
+	assert.Nil(t, err)
+	assert.Equal(t, DescribeKind, ast.Statements[0].Kind)
+	assert.Equal(t, "users", ast.Statements[0].DescribeStatement.Table.Value)
+	assert.Equal(t, DescribeKind, ast.Statements[1].Kind)
+	assert.Equal(t, "Orders", ast.Statements[1].DescribeStatement.Table.Value)
+	assert.Equal(t, SelectKind, ast.Statements[2].Kind)
+	assert.False(t, ast.Statements[2].SelectStatement.OrderBy[0].Asc)
+	assert.Equal(t, DescribeKind, ast.Statements[3].Kind)
+	assert.Equal(t, "t", ast.Statements[3].DescribeStatement.Table.Value)
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "describe;",
+			err:    `expected identifier but found symbol ";" at line 1 col 9`,
+		},
+		{
+			source: "desc",
+			err:    "expected identifier but found end of input at line 1 col 5",
+		},
+		{
+			source: "describe table users",
+			err:    `expected identifier but found keyword "table" at line 1 col 10`,
+		},
+		{
+			source: "desc users, orders",
+			err:    `expected ';' but found symbol "," at line 1 col 11`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_createView(t *testing.T) {
+	ast, err := Parse("create view active_users as select * from users where active = 1;")
+	assert.Nil(t, err)
+	stmt := ast.Statements[0]
+	assert.Equal(t, CreateViewKind, stmt.Kind)
+	view := stmt.CreateViewStatement
+	assert.Equal(t, "active_users", view.Name.Value)
+	assert.False(t, view.OrReplace)
+	assert.Equal(t, "users", view.Select.From.Name.Value)
+	assert.Equal(t, "(= active 1)", sexp(view.Select.Where))
+
+	ast, err = Parse(`CREATE OR REPLACE VIEW "Totals" AS
+select u.name, sum(o.total) as total from users u join orders o on u.id = o.user_id
+where o.total > 0 group by u.name having count(*) > 1 order by total desc limit 10`)
+	assert.Nil(t, err)
+	view = ast.Statements[0].CreateViewStatement
+	assert.True(t, view.OrReplace)
+	assert.Equal(t, "Totals", view.Name.Value)
+	assert.Equal(t, 1, len(view.Select.Joins))
+	assert.Equal(t, "10", sexp(view.Select.Limit))
+
+	ast, err = Parse("drop view active_users; DROP VIEW \"Totals\"")
+	assert.Nil(t, err)
+	assert.Equal(t, DropViewKind, ast.Statements[0].Kind)
+	assert.Equal(t, "active_users", ast.Statements[0].DropViewStatement.Name.Value)
+	assert.Equal(t, "Totals", ast.Statements[1].DropViewStatement.Name.Value)
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "create view v;",
+			err:    `expected AS and the SELECT of view v but found symbol ";" at line 1 col 14`,
+		},
+		{
+			source: "create view v as;",
+			err:    `expected SELECT of view v but found symbol ";" at line 1 col 17`,
+		},
+		{
+			source: "create view v as insert into t values (1)",
+			err:    `expected SELECT of view v but found keyword "insert" at line 1 col 18`,
+		},
+		{
+			source: "create or view v as select 1",
+			err:    `expected REPLACE but found keyword "view" at line 1 col 11`,
+		},
+		{
+			source: "create or replace table t (a int)",
+			err:    `expected VIEW but found keyword "table" at line 1 col 19`,
+		},
+		{
+			source: "create view as select 1",
+			err:    `expected identifier but found keyword "as" at line 1 col 13`,
+		},
+		{
+			source: "drop view",
+			err:    "expected identifier but found end of input at line 1 col 10",
+		},
+		{
+			source: "drop v",
+			err:    `expected VIEW or INDEX but found identifier "v" at line 1 col 6`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_dropIndex(t *testing.T) {
+	ast, err := Parse("drop index idx_name; DROP INDEX IF EXISTS \"Idx\" ON users")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(ast.Statements))
+
+	stmt := ast.Statements[0]
+	assert.Equal(t, DropIndexKind, stmt.Kind)
+	assert.Equal(t, "idx_name", stmt.DropIndexStatement.Name.Value)
+	assert.False(t, stmt.DropIndexStatement.IfExists)
+	assert.Nil(t, stmt.DropIndexStatement.Table)
+
+	stmt = ast.Statements[1]
+	assert.Equal(t, DropIndexKind, stmt.Kind)
+	assert.Equal(t, "Idx", stmt.DropIndexStatement.Name.Value)
+	assert.True(t, stmt.DropIndexStatement.IfExists)
+	assert.Equal(t, "users", stmt.DropIndexStatement.Table.Value)
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "drop index;",
+			err:    `expected identifier but found symbol ";" at line 1 col 11`,
+		},
+		{
+			source: "drop index if exists",
+			err:    "expected identifier but found end of input at line 1 col 21",
+		},
+		{
+			source: "drop index if i",
+			err:    `expected EXISTS but found identifier "i" at line 1 col 15`,
+		},
+		{
+			source: "drop index i on;",
+			err:    `expected identifier but found symbol ";" at line 1 col 16`,
+		},
+		{
+			source: "drop index i users",
+			err:    `expected ';' but found identifier "users" at line 1 col 14`,
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_datatypes(t *testing.T) {
+	ast, err := Parse(`create table t (
+	a boolean, b real, c float, d float(24), e numeric, f numeric(10), g decimal(10, 2),
+	h varchar(255), i char(1), j bigint not null, k VARCHAR
+)`)
+	assert.Nil(t, err)
+	cols := ast.Statements[0].CreateTableStatement.Cols
+
+	var types []string
+	var params [][]string
+	for _, col := range cols {
+		types = append(types, col.Datatype.Value)
+		var values []string
+		for _, p := range col.Params {
+			assert.Equal(t, NumericKind, p.Kind)
+			values = append(values, p.Value)
+		}
+		params = append(params, values)
+	}
+	assert.Equal(t, []string{"boolean", "real", "float", "float", "numeric", "numeric", "decimal", "varchar", "char", "bigint", "varchar"}, types)
+	assert.Equal(t, [][]string{nil, nil, nil, {"24"}, nil, {"10"}, {"10", "2"}, {"255"}, {"1"}, nil, nil}, params)
+	assert.True(t, cols[9].NotNull)
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "create table t (a varchar(-1))",
+			err:    `expected non-negative integer but found operator "-" at line 1 col 27`,
+		},
+		{
+			source: "create table t (a numeric(10, 2.5))",
+			err:    "parameter 2.5 of NUMERIC is not a non-negative integer at line 1 col 31",
+		},
+		{
+			source: "create table t (a char(0x10))",
+			err:    "parameter 0x10 of CHAR is not a non-negative integer at line 1 col 24",
+		},
+		{
+			source: "create table t (a varchar(10, 2))",
+			err:    "too many parameters for VARCHAR, at most 1 at line 1 col 31",
+		},
+		{
+			source: "create table t (a decimal(10, 2, 3))",
+			err:    "too many parameters for DECIMAL, at most 2 at line 1 col 34",
+		},
+		{
+			source: "create table t (a int(11))",
+			err:    "INT does not take parameters at line 1 col 22",
+		},
+		{
+			source: "create table t (a varchar())",
+			err:    `expected non-negative integer but found symbol ")" at line 1 col 27`,
+		},
+		{
+			source: "create table t (a varchar(10 not null)",
+			err:    `expected ',' or ')' but found keyword "not" at line 1 col 30`,
+		},
+		{
+			source: "create table t (a numeric(",
+			err:    "expected non-negative integer but found end of input at line 1 col 27",
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_temporal(t *testing.T) {
+	ast, err := Parse("create table events (created timestamp not null, day date, at time)")
+	assert.Nil(t, err)
+	var types []string
+	for _, col := range ast.Statements[0].CreateTableStatement.Cols {
+		types = append(types, col.Datatype.Value)
+	}
+	assert.Equal(t, []string{"timestamp", "date", "time"}, types)
+
+	tests := []struct {
+		source string
+		tree   string
+	}{
+		{"select date '2024-01-15'", "(date 2024-01-15)"},
+		{"select TIME '10:30:00'", "(time 10:30:00)"},
+		{"select time '23:59:59.123456'", "(time 23:59:59.123456)"},
+		{"select timestamp '2024-01-15 10:30:00'", "(timestamp 2024-01-15 10:30:00)"},
+		{"select timestamp '2024-02-29 00:00:00.5'", "(timestamp 2024-02-29 00:00:00.5)"},
+		{"select date '2024-01-15'::text", "(:: (date 2024-01-15) text)"},
+		{"select created >= timestamp '2024-01-01 00:00:00' + 1", "(>= created (+ (timestamp 2024-01-01 00:00:00) 1))"},
+		{"select x::date", "(:: x date)"},
+	}
+	for _, test := range tests {
+		ast, err := Parse(test.source)
+		assert.Nil(t, err, test.source)
+		assert.Equal(t, test.tree, sexp(ast.Statements[0].SelectStatement.Item[0].Exp), test.source)
+	}
+
+	errors := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "select date 'notadate'",
+			err:    "invalid DATE literal 'notadate', expected format YYYY-MM-DD at line 1 col 13",
+		},
+		{
+			source: "select date '2023-02-29'",
+			err:    "invalid DATE literal '2023-02-29', expected format YYYY-MM-DD at line 1 col 13",
+		},
+		{
+			source: "select time '24:00:00'",
+			err:    "invalid TIME literal '24:00:00', expected format HH:MM:SS[.ffffff] at line 1 col 13",
+		},
+		{
+			source: "select timestamp '2024-01-15'",
+			err:    "invalid TIMESTAMP literal '2024-01-15', expected format YYYY-MM-DD HH:MM:SS[.ffffff] at line 1 col 18",
+		},
+		{
+			source: "select timestamp '2024-01-15 10:30:00+02:00'",
+			err:    "time zone in TIMESTAMP literal '2024-01-15 10:30:00+02:00' is not supported at line 1 col 18",
+		},
+		{
+			source: "select timestamp '2024-01-15 10:30:00Z'",
+			err:    "time zone in TIMESTAMP literal '2024-01-15 10:30:00Z' is not supported at line 1 col 18",
+		},
+		{
+			source: "select time '10:30:00 UTC'",
+			err:    "time zone in TIME literal '10:30:00 UTC' is not supported at line 1 col 13",
+		},
+		{
+			source: "select time '10:30:00-0800'",
+			err:    "time zone in TIME literal '10:30:00-0800' is not supported at line 1 col 13",
+		},
+		{
+			source: "select date 20240115",
+			err:    `expected string literal but found numeric "20240115" at line 1 col 13`,
+		},
+		{
+			source: "select date",
+			err:    "expected string literal but found end of input at line 1 col 12",
+		},
+	}
+	for _, test := range errors {
+		_, err := Parse(test.source)
+		assert.EqualError(t, err, test.err, test.source)
+	}
+}
+
+func TestParse_maxExpressionDepth(t *testing.T) {
+	nested := func(open, close string, n int) string {
+		return "select " + strings.Repeat(open, n) + "1" + strings.Repeat(close, n)
+	}
+
+	// 没有上限时这些输入会让递归下降耗尽栈
+	_, err := Parse("select " + strings.Repeat("(", 100000))
+	assert.EqualError(t, err, "expression nested deeper than the limit of 200 at line 1 col 208")
+
+	_, err = Parse("select " + strings.Repeat("not ", 100000) + "true")
+	assert.EqualError(t, err, "expression nested deeper than the limit of 200 at line 1 col 808")
+
+	_, err = Parse("select " + strings.Repeat("-(", 100000) + "1")
+	assert.EqualError(t, err, "expression nested deeper than the limit of 200 at line 1 col 208")
+
+	_, err = Parse(strings.Repeat("select * from t where a in (", 201) + "1" + strings.Repeat(")", 201))
+	assert.EqualError(t, err, "expression nested deeper than the limit of 200 at line 1 col 5628")
+
+	// 合理的嵌套不受影响
+	tests := []string{
+		nested("(", ")", 200),
+		nested("-(", ")", 100),
+		nested("not (", ")", 100),
+		nested("case when true then ", " end", 200),
+		"select " + strings.Repeat("1 - ", 1000) + "1",
+		"select " + strings.Repeat("-a - ", 1000) + "1",
+		"select a from t where " + strings.Repeat("a not in (1) and not b and ", 500) + "true",
+		strings.Repeat("select * from t where a in (", 50) + "1" + strings.Repeat(")", 50),
+		strings.Repeat(nested("(", ")", 150)+";", 3),
+	}
+	for _, source := range tests {
+		_, err := Parse(source)
+		assert.Nil(t, err, source)
+	}
+
+	_, err = ParseWithOptions(nested("(", ")", 3), ParseOptions{MaxExpressionDepth: 3})
+	assert.Nil(t, err)
+	_, err = ParseWithOptions(nested("(", ")", 4), ParseOptions{MaxExpressionDepth: 3})
+	assert.EqualError(t, err, "expression nested deeper than the limit of 3 at line 1 col 11")
+	_, err = ParseWithOptions(nested("(", ")", 250), ParseOptions{MaxExpressionDepth: 300})
+	assert.Nil(t, err)
+	_, err = ParseWithOptions("select 1", ParseOptions{MaxExpressionDepth: -1})
+	assert.EqualError(t, err, "MaxExpressionDepth must not be negative, got -1")
+
+	// 函数调用、cast 和 from 中的子查询也是一层嵌套
+	_, err = Parse(nested("f(", ")", 201))
+	assert.EqualError(t, err, "expression nested deeper than the limit of 200 at line 1 col 409")
+	_, err = Parse(nested("cast(", " as int)", 200))
+	assert.Nil(t, err)
+	_, err = Parse("select * from " + strings.Repeat("(select * from ", 201) + "t" + strings.Repeat(") as t", 201))
+	assert.EqualError(t, err, "expression nested deeper than the limit of 200 at line 1 col 3015")
+
+	// ParseAll 报告错误后继续解析下一条语句
+	ast, errs := ParseAll(nested("(", ")", 201) + "; select 2")
+	assert.Equal(t, 1, len(ast.Statements))
+	assert.Equal(t, 1, len(errs))
+	assert.EqualError(t, errs[0], "expression nested deeper than the limit of 200 at line 1 col 208")
+}
+
+func TestParse_locations(t *testing.T) {
+	source := `select id, name
+  from users
+  where id = 1;
+insert into users
+values (1, 'Ada');
+delete from users where id = 2`
+	ast, err := Parse(source)
+	assert.Nil(t, err)
+
+	type lines struct{ start, end uint }
+	var got []lines
+	for _, stmt := range ast.Statements {
+		got = append(got, lines{stmt.Start.Line, stmt.End.Line})
+	}
+	assert.Equal(t, []lines{{0, 2}, {3, 4}, {5, 5}}, got)
+
+	slct := ast.Statements[0].SelectStatement
+	assert.Equal(t, Location{Line: 0, Col: 0}, slct.Start)
+	assert.Equal(t, Location{Line: 2, Col: 14}, slct.End)
+	assert.Equal(t, Location{Line: 2, Col: 8}, slct.Where.Start)
+	assert.Equal(t, Location{Line: 2, Col: 14}, slct.Where.End)
+
+	inst := ast.Statements[1].InsertStatement
+	assert.Equal(t, Location{Line: 3, Col: 0}, inst.Start)
+	assert.Equal(t, Location{Line: 4, Col: 17}, inst.End)
+
+	dlt := ast.Statements[2].DeleteStatement
+	assert.Equal(t, Location{Line: 5, Col: 0}, dlt.Start)
+	assert.Equal(t, Location{Line: 5, Col: 30}, dlt.End)
+
+	// 单行源码中节点覆盖的文本
+	text := func(source string, start, end Location) string {
+		return source[start.Col:end.Col]
+	}
+
+	source = "select (a + b) * c, -x::int, ((1)), f(a, 2) is not null, not a in (select 1), date '2024-01-15' from t"
+	ast, err = Parse(source)
+	assert.Nil(t, err)
+	var items []string
+	for _, item := range ast.Statements[0].SelectStatement.Item {
+		items = append(items, text(source, item.Exp.Start, item.Exp.End))
+	}
+	assert.Equal(t, []string{"(a + b) * c", "-x::int", "1", "f(a, 2) is not null", "not a in (select 1)", "date '2024-01-15'"}, items)
+
+	exp := ast.Statements[0].SelectStatement.Item[0].Exp
+	assert.Equal(t, "a + b", text(source, exp.Binary.A.Start, exp.Binary.A.End))
+	assert.Equal(t, "c", text(source, exp.Binary.B.Start, exp.Binary.B.End))
+	in := ast.Statements[0].SelectStatement.Item[4].Exp.Unary.Operand.In
+	assert.Equal(t, "select 1", text(source, in.Select.Start, in.Select.End))
+
+	// 提到集合运算上的 order by 和 limit 不再属于最后一个 select
+	source = "select a from t union (select b from u intersect select 1) except select c from v order by a limit 1"
+	ast, err = Parse(source)
+	assert.Nil(t, err)
+	compound := ast.Statements[0].CompoundSelectStatement
+	assert.Equal(t, source, text(source, compound.Start, compound.End))
+	var operands []string
+	for _, operand := range compound.Selects {
+		if operand.Select != nil {
+			operands = append(operands, text(source, operand.Select.Start, operand.Select.End))
+		} else {
+			operands = append(operands, text(source, operand.Compound.Start, operand.Compound.End))
+		}
+	}
+	assert.Equal(t, []string{"select a from t", "select b from u intersect select 1", "select c from v"}, operands)
+
+	// 子句的表达式带括号时也一样
+	for _, source := range []string{
+		"select a from t union select b from u order by (b)",
+		"select a from t union select b from u limit (5)",
+		"select a from t union select b from u offset ((1))",
+	} {
+		ast, err = Parse(source)
+		assert.Nil(t, err, source)
+		compound := ast.Statements[0].CompoundSelectStatement
+		last := compound.Selects[1].Select
+		assert.Equal(t, Location{Line: 0, Col: 37}, last.End, source)
+		assert.Equal(t, "select b from u", text(source, last.Start, last.End), source)
+		assert.Equal(t, source, text(source, compound.Start, compound.End), source)
+	}
+
+	source = "select b from u order by (b) limit (5)"
+	ast, err = Parse(source)
+	assert.Nil(t, err)
+	assert.Equal(t, source, text(source, ast.Statements[0].SelectStatement.Start, ast.Statements[0].SelectStatement.End))
+
+	source = "explain analyze update t set a = 1"
+	ast, err = Parse(source)
+	assert.Nil(t, err)
+	explain := ast.Statements[0].ExplainStatement
+	assert.Equal(t, source, text(source, explain.Start, explain.End))
+	assert.Equal(t, "update t set a = 1", text(source, explain.Statement.Start, explain.Statement.End))
+	assert.Equal(t, "update t set a = 1", text(source, explain.Statement.UpdateStatement.Start, explain.Statement.UpdateStatement.End))
+}
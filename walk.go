@@ -0,0 +1,283 @@
+package gosql
+
+// Node 是语法树中的节点，只有本包中的类型实现它
+type Node interface {
+	node()
+}
+
+func (*Ast) node()                     {}
+func (*Statement) node()               {}
+func (*SelectStatement) node()         {}
+func (*CompoundSelectStatement) node() {}
+func (*SetOperand) node()              {}
+func (*ValuesStatement) node()         {}
+func (*SelectItem) node()              {}
+func (*OrderByItem) node()             {}
+func (*TableReference) node()          {}
+func (*Join) node()                    {}
+func (*InsertStatement) node()         {}
+func (*DeleteStatement) node()         {}
+func (*TruncateStatement) node()       {}
+func (*ExplainStatement) node()        {}
+func (*ShowStatement) node()           {}
+func (*DescribeStatement) node()       {}
+func (*UpdateStatement) node()         {}
+func (*Assignment) node()              {}
+func (*CreateTableStatement) node()    {}
+func (*CreateIndexStatement) node()    {}
+func (*CreateViewStatement) node()     {}
+func (*DropIndexStatement) node()      {}
+func (*DropViewStatement) node()       {}
+func (*Expression) node()              {}
+func (*BinaryExpression) node()        {}
+func (*UnaryExpression) node()         {}
+func (*FunctionCallExpression) node()  {}
+func (*CaseExpression) node()          {}
+func (*CastExpression) node()          {}
+func (*InExpression) node()            {}
+func (*BetweenExpression) node()       {}
+func (*LikeExpression) node()          {}
+func (*IsNullExpression) node()        {}
+func (*ExistsExpression) node()        {}
+func (*TypedLiteralExpression) node()  {}
+func (*ColumnReference) node()         {}
+func (*ColumnDefinition) node()        {}
+func (*ForeignKey) node()              {}
+
+// Walk 深度优先遍历语法树，先访问节点本身，再按源码顺序访问子节点。
+// visitor 返回 false 时跳过该节点的子节点。
+func Walk(node Node, visitor func(Node) bool) {
+	if !visitor(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Ast:
+		for _, stmt := range n.Statements {
+			Walk(stmt, visitor)
+		}
+	case *Statement:
+		switch {
+		case n.SelectStatement != nil:
+			Walk(n.SelectStatement, visitor)
+		case n.CreateTableStatement != nil:
+			Walk(n.CreateTableStatement, visitor)
+		case n.InsertStatement != nil:
+			Walk(n.InsertStatement, visitor)
+		case n.DeleteStatement != nil:
+			Walk(n.DeleteStatement, visitor)
+		case n.UpdateStatement != nil:
+			Walk(n.UpdateStatement, visitor)
+		case n.CreateIndexStatement != nil:
+			Walk(n.CreateIndexStatement, visitor)
+		case n.CompoundSelectStatement != nil:
+			Walk(n.CompoundSelectStatement, visitor)
+		case n.ValuesStatement != nil:
+			Walk(n.ValuesStatement, visitor)
+		case n.TruncateStatement != nil:
+			Walk(n.TruncateStatement, visitor)
+		case n.ExplainStatement != nil:
+			Walk(n.ExplainStatement, visitor)
+		case n.ShowStatement != nil:
+			Walk(n.ShowStatement, visitor)
+		case n.DescribeStatement != nil:
+			Walk(n.DescribeStatement, visitor)
+		case n.CreateViewStatement != nil:
+			Walk(n.CreateViewStatement, visitor)
+		case n.DropViewStatement != nil:
+			Walk(n.DropViewStatement, visitor)
+		case n.DropIndexStatement != nil:
+			Walk(n.DropIndexStatement, visitor)
+		}
+	case *SelectStatement:
+		for _, item := range n.Item {
+			Walk(item, visitor)
+		}
+		if n.From != nil {
+			Walk(n.From, visitor)
+		}
+		for _, join := range n.Joins {
+			Walk(join, visitor)
+		}
+		if n.Where != nil {
+			Walk(n.Where, visitor)
+		}
+		for _, exp := range n.GroupBy {
+			Walk(exp, visitor)
+		}
+		if n.Having != nil {
+			Walk(n.Having, visitor)
+		}
+		for _, item := range n.OrderBy {
+			Walk(item, visitor)
+		}
+		if n.Limit != nil {
+			Walk(n.Limit, visitor)
+		}
+		if n.Offset != nil {
+			Walk(n.Offset, visitor)
+		}
+	case *CompoundSelectStatement:
+		for _, operand := range n.Selects {
+			Walk(operand, visitor)
+		}
+		for _, item := range n.OrderBy {
+			Walk(item, visitor)
+		}
+		if n.Limit != nil {
+			Walk(n.Limit, visitor)
+		}
+		if n.Offset != nil {
+			Walk(n.Offset, visitor)
+		}
+	case *SetOperand:
+		if n.Select != nil {
+			Walk(n.Select, visitor)
+		}
+		if n.Compound != nil {
+			Walk(n.Compound, visitor)
+		}
+	case *OrderByItem:
+		Walk(n.Exp, visitor)
+	case *InsertStatement:
+		for _, row := range n.Values {
+			for _, exp := range row {
+				Walk(exp, visitor)
+			}
+		}
+		if n.Select != nil {
+			Walk(n.Select, visitor)
+		}
+	case *ExplainStatement:
+		Walk(n.Statement, visitor)
+	case *CreateViewStatement:
+		Walk(n.Select, visitor)
+	case *DeleteStatement:
+		if n.Where != nil {
+			Walk(n.Where, visitor)
+		}
+	case *UpdateStatement:
+		for _, a := range n.Set {
+			Walk(a, visitor)
+		}
+		if n.Where != nil {
+			Walk(n.Where, visitor)
+		}
+	case *Assignment:
+		Walk(n.Value, visitor)
+	case *CreateTableStatement:
+		for _, col := range n.Cols {
+			Walk(col, visitor)
+		}
+		for _, fk := range n.ForeignKeys {
+			Walk(fk, visitor)
+		}
+		for _, check := range n.Checks {
+			Walk(check, visitor)
+		}
+	case *ColumnDefinition:
+		if n.Default != nil {
+			Walk(n.Default, visitor)
+		}
+		for _, check := range n.Checks {
+			Walk(check, visitor)
+		}
+		if n.References != nil {
+			Walk(n.References, visitor)
+		}
+	case *SelectItem:
+		if n.Exp != nil {
+			Walk(n.Exp, visitor)
+		}
+	case *Expression:
+		switch {
+		case n.Binary != nil:
+			Walk(n.Binary, visitor)
+		case n.Unary != nil:
+			Walk(n.Unary, visitor)
+		case n.Call != nil:
+			Walk(n.Call, visitor)
+		case n.Case != nil:
+			Walk(n.Case, visitor)
+		case n.Cast != nil:
+			Walk(n.Cast, visitor)
+		case n.In != nil:
+			Walk(n.In, visitor)
+		case n.Between != nil:
+			Walk(n.Between, visitor)
+		case n.Like != nil:
+			Walk(n.Like, visitor)
+		case n.IsNull != nil:
+			Walk(n.IsNull, visitor)
+		case n.Exists != nil:
+			Walk(n.Exists, visitor)
+		case n.Column != nil:
+			Walk(n.Column, visitor)
+		case n.TypedLiteral != nil:
+			Walk(n.TypedLiteral, visitor)
+		}
+	case *BinaryExpression:
+		Walk(n.A, visitor)
+		Walk(n.B, visitor)
+	case *UnaryExpression:
+		Walk(n.Operand, visitor)
+	case *FunctionCallExpression:
+		for _, arg := range n.Args {
+			Walk(arg, visitor)
+		}
+	case *CastExpression:
+		Walk(n.Exp, visitor)
+	case *InExpression:
+		Walk(n.Left, visitor)
+		for _, exp := range n.List {
+			Walk(exp, visitor)
+		}
+		if n.Select != nil {
+			Walk(n.Select, visitor)
+		}
+	case *BetweenExpression:
+		Walk(n.Exp, visitor)
+		Walk(n.Low, visitor)
+		Walk(n.High, visitor)
+	case *LikeExpression:
+		Walk(n.Exp, visitor)
+		Walk(n.Pattern, visitor)
+		if n.Escape != nil {
+			Walk(n.Escape, visitor)
+		}
+	case *IsNullExpression:
+		Walk(n.Exp, visitor)
+	case *ExistsExpression:
+		Walk(n.Select, visitor)
+	case *CaseExpression:
+		if n.Operand != nil {
+			Walk(n.Operand, visitor)
+		}
+		for _, w := range n.Whens {
+			Walk(w.When, visitor)
+			Walk(w.Then, visitor)
+		}
+		if n.Else != nil {
+			Walk(n.Else, visitor)
+		}
+	case *TableReference:
+		if n.Select != nil {
+			Walk(n.Select, visitor)
+		}
+		if n.Values != nil {
+			Walk(n.Values, visitor)
+		}
+	case *ValuesStatement:
+		for _, row := range n.Rows {
+			for _, exp := range row {
+				Walk(exp, visitor)
+			}
+		}
+	case *Join:
+		Walk(n.Table, visitor)
+		Walk(n.On, visitor)
+	case *ColumnReference, *TypedLiteralExpression, *ForeignKey, *CreateIndexStatement, *TruncateStatement, *ShowStatement, *DescribeStatement, *DropViewStatement,
+		*DropIndexStatement:
+		// 叶子节点
+	}
+}
@@ -0,0 +1,99 @@
+package gosql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalk_tableNames(t *testing.T) {
+	ast, err := Parse(`create table users (id int, name text);
+create table orders (id int, user_id int references users, item_id int, foreign key (item_id) references items);
+insert into orders values (1, 2, 3);
+select id from (select id from users) as u;
+select total from orders join items on orders.item_id = items.id where user_id not in (select id from admins);
+select id from archived_users union select user_id from orders;
+select 1 from (values (1)) as v join logins on true;
+delete from sessions where user_id = 1;
+update accounts set balance = balance - 1;
+truncate audit;
+explain select 1 from plans;
+create view recent as select * from visits;`)
+	assert.Nil(t, err)
+
+	// 收集脚本中出现的所有表名，按第一次出现的顺序去重
+	var tables []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			tables = append(tables, name)
+		}
+	}
+	Walk(ast, func(n Node) bool {
+		switch n := n.(type) {
+		case *TableReference:
+			if n.Name != nil {
+				add(n.Name.Value)
+			}
+		case *InsertStatement:
+			add(n.Table.Value)
+		case *CreateTableStatement:
+			add(n.Name.Value)
+		case *DeleteStatement:
+			add(n.From.Value)
+		case *UpdateStatement:
+			add(n.Table.Value)
+		case *ForeignKey:
+			add(n.Table.Value)
+		case *TruncateStatement:
+			for _, table := range n.Tables {
+				add(table.Value)
+			}
+		}
+		return true
+	})
+
+	assert.Equal(t, []string{"users", "orders", "items", "admins", "archived_users", "logins", "sessions", "accounts", "audit", "plans", "visits"}, tables)
+}
+
+func TestWalk_order(t *testing.T) {
+	ast, err := Parse("create table t (a int default 0); insert into t values (1, 'x')")
+	assert.Nil(t, err)
+
+	var visited []string
+	Walk(ast, func(n Node) bool {
+		switch n := n.(type) {
+		case *Ast:
+			visited = append(visited, "ast")
+		case *Statement:
+			visited = append(visited, n.Kind.String())
+		case *ColumnDefinition:
+			visited = append(visited, "col "+n.Name.Value)
+		case *Expression:
+			visited = append(visited, "exp "+n.Literal.Value)
+		}
+		return true
+	})
+
+	assert.Equal(t, []string{"ast", "create_table", "col a", "exp 0", "insert", "exp 1", "exp x"}, visited)
+}
+
+func TestWalk_skipChildren(t *testing.T) {
+	ast, err := Parse("select a, b from t; insert into t values (1)")
+	assert.Nil(t, err)
+
+	expressions := 0
+	Walk(ast, func(n Node) bool {
+		if _, ok := n.(*SelectStatement); ok {
+			return false
+		}
+		if _, ok := n.(*Expression); ok {
+			expressions++
+		}
+		return true
+	})
+
+	// select 的两个表达式被跳过了
+	assert.Equal(t, 1, expressions)
+}